@@ -31,32 +31,37 @@ var agentCmd = &cobra.Command{
 		initConfig()
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		apiUrl := os.Getenv("IACCONSOLE_API_URL")
-		wsURL, authHeader, accountID, err := utils.ParseAPIURL(apiUrl)
+		apiEndpoint, err := getApiEndpoint()
 		if err != nil {
 			log.Fatalf("Configuration error: %v", err)
 		}
+		if apiEndpoint == nil || apiEndpoint.AccountID == "" {
+			log.Fatalf("Configuration error: IACCONSOLE_API_URL with credentials (or IACCONSOLE_API_ACCOUNT_ID and IACCONSOLE_API_PASSWORD) must be set")
+		}
+		wsURL := apiEndpoint.WebSocketUrl("/v1/ws/agent")
 
 		// Generate unique agent ID (hostname + random suffix)
 		agentID := generateAgentID()
 
-		log.Printf("Starting agent for account: %s", accountID)
+		log.Printf("Starting agent for account: %s", apiEndpoint.AccountID)
 		log.Printf("Agent ID: %s", agentID)
 		log.Printf("Connecting to: %s", wsURL)
 
-		runAgent(wsURL, authHeader, agentID)
+		runAgent(wsURL, apiEndpoint, agentID)
 	},
 }
 
-func runAgent(wsURL, authHeader, agentID string) {
+func runAgent(wsURL string, apiEndpoint *utils.ApiEndpoint, agentID string) {
 	interrupt := make(chan os.Signal, 1)
 	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
 
 	for {
 		header := http.Header{}
-		header.Add("Authorization", authHeader)
+		header.Add("Authorization", apiEndpoint.Authorization())
 
-		c, _, err := websocket.DefaultDialer.Dial(wsURL, header)
+		dialer := *websocket.DefaultDialer
+		dialer.TLSClientConfig = apiEndpoint.TLSConfig()
+		c, _, err := dialer.Dial(wsURL, header)
 		if err != nil {
 			log.Printf("Dial error: %v. Retrying in 5s...", err)
 			select {
@@ -228,8 +233,10 @@ func executeCommand(c *websocket.Conn, cmd utils.AgentCommand, autoExecute bool)
 	}
 
 	state := &utils.State{}
-	state.IacconsoleApiUrl = os.Getenv("IACCONSOLE_API_URL")
+	state.ApiEndpoint, _ = getApiEndpoint()
 	state.StateS3Path = "./state"
+	state.Verbose = Verbose
+	state.Offline = Offline
 
 	utils.ExecuteAgentCommand(c, cmd, state)
 }
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"text/tabwriter"
+	"time"
+)
+
+// newSelfExecCommand builds a child "iacconsole-cli exec" process with the given exec flags and tofu/terraform args
+func newSelfExecCommand(execFlags []string, tofuArgs []string) (*exec.Cmd, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate iacconsole-cli executable: %v", err)
+	}
+
+	var childArgs []string
+	if cfgFile != "" {
+		childArgs = append(childArgs, "--config", cfgFile)
+	}
+	if Verbose {
+		childArgs = append(childArgs, "--verbose")
+	}
+	if Offline {
+		childArgs = append(childArgs, "--offline")
+	}
+	childArgs = append(childArgs, "exec")
+	childArgs = append(childArgs, execFlags...)
+	childArgs = append(childArgs, "--")
+	childArgs = append(childArgs, tofuArgs...)
+
+	childCommand := exec.Command(self, childArgs...)
+	childCommand.Env = os.Environ()
+	return childCommand, nil
+}
+
+// runChildCommand starts the child, passes signals from sigs to it while it runs and returns its exit code
+func runChildCommand(childCommand *exec.Cmd, sigs <-chan os.Signal) int {
+	if err := childCommand.Start(); err != nil {
+		log.Printf("cmd.Start() failed with %s", err)
+		return 1
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-sigs:
+				log.Println("Got singnal +" + sig.String())
+				if err := childCommand.Process.Signal(sig); err != nil {
+					log.Printf("Failed to send signal to child process: %v", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err := childCommand.Wait()
+	if err != nil && childCommand.ProcessState.ExitCode() < 0 {
+		log.Println("child failed " + err.Error())
+		return 1
+	}
+	return childCommand.ProcessState.ExitCode()
+}
+
+// runResult holds the outcome of one child execution for the summary table
+type runResult struct {
+	Name     string
+	Status   string
+	ExitCode int
+	Duration time.Duration
+}
+
+// printRunResults prints summary table of child executions
+func printRunResults(title string, nameHeader string, results []runResult) {
+	fmt.Println("\n" + title + ":")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, nameHeader+"\tSTATUS\tEXIT CODE\tDURATION")
+	for _, result := range results {
+		exitCode := "-"
+		if result.ExitCode >= 0 {
+			exitCode = fmt.Sprint(result.ExitCode)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", result.Name, result.Status, exitCode, result.Duration)
+	}
+	w.Flush()
+}
@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	driftStatusNoDrift = "no_drift"
+	driftStatusDrift   = "drift"
+	driftStatusError   = "error"
+)
+
+// driftReport is the machine-readable report of the drift command
+type driftReport struct {
+	Org       string             `json:"org"`
+	Unit      string             `json:"unit"`
+	Workspace string             `json:"workspace"`
+	StartedAt time.Time          `json:"started_at"`
+	Results   []driftReportEntry `json:"results"`
+}
+
+type driftReportEntry struct {
+	Dimensions      map[string]string `json:"dimensions"`
+	StatePath       string            `json:"state_path"`
+	Status          string            `json:"status"`
+	ExitCode        int               `json:"exit_code"`
+	DurationSeconds float64           `json:"duration_seconds"`
+}
+
+type junitTestSuites struct {
+	XMLName    xml.Name         `xml:"testsuites"`
+	TestSuites []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+}
+
+// driftCmd represents the drift command
+var driftCmd = &cobra.Command{
+	Use:   "drift",
+	Short: "Detect drift of the unit across dimension combinations",
+	Long: `Executes init and plan -detailed-exitcode for the unit for every dimension combination (like exec matrix).
+Plan exit code 2 is reported as drift, any other non-zero code as error.
+Optional args after -- are passed to plan.
+Exit code is 1 if any combination failed, 2 if drift detected, 0 otherwise.`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		initConfig()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		reportJsonPath, _ := cmd.Flags().GetString("report-json")
+		reportJunitPath, _ := cmd.Flags().GetString("report-junit")
+		parallelism, _ := cmd.Flags().GetInt("parallelism")
+
+		report := driftReport{StartedAt: time.Now().UTC()}
+		report.Org, _ = cmd.Flags().GetString("org")
+		report.Unit, _ = cmd.Flags().GetString("unit")
+		report.Workspace, _ = cmd.Flags().GetString("workspace")
+
+		dimCombinations, err := getDimCombinations(cmd)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+
+		// State paths are calculated here the same way as in exec, to be included in the report
+		report.Results = make([]driftReportEntry, len(dimCombinations))
+		for i, dimCombination := range dimCombinations {
+			s, err := newStateFromFlags(cmd, dimCombination)
+			if err == nil {
+				err = s.ParseUnitManifest("unit_manifest.json")
+			}
+			if err == nil {
+				err = s.ParseDimensions()
+			}
+			if err != nil {
+				log.Fatalf("Failed to load unit: %v", err)
+			}
+			s.SetupBackendConfig()
+			report.Results[i] = driftReportEntry{Dimensions: s.ParsedDimensions, StatePath: s.StateS3Path}
+		}
+
+		log.Printf("checking drift of unit %s for %d dimension combinations with parallelism %d", report.Unit, len(dimCombinations), parallelism)
+
+		planArgs := append([]string{"plan", "-detailed-exitcode", "-input=false"}, args...)
+		results := runDimCombinations(dimCombinations, parallelism, func(dimCombination []string, stdout io.Writer, stderr io.Writer, sigs <-chan os.Signal) runResult {
+			runStep := func(childArgs []string) (int, error) {
+				childCommand, err := newSelfExecCommand(getExecFlags(cmd, dimCombination), childArgs)
+				if err != nil {
+					return 1, err
+				}
+				childCommand.Stdout = stdout
+				childCommand.Stderr = stderr
+				return runChildCommand(childCommand, sigs), nil
+			}
+
+			initExitCode, err := runStep([]string{"init", "-input=false"})
+			if err != nil {
+				log.Printf("Failed to prepare child command: %v", err)
+				return runResult{ExitCode: 1, Status: driftStatusError}
+			}
+			if initExitCode != 0 {
+				return runResult{ExitCode: initExitCode, Status: driftStatusByExitCodes(initExitCode, -1)}
+			}
+			planExitCode, err := runStep(planArgs)
+			if err != nil {
+				log.Printf("Failed to prepare child command: %v", err)
+				return runResult{ExitCode: 1, Status: driftStatusError}
+			}
+			return runResult{ExitCode: planExitCode, Status: driftStatusByExitCodes(initExitCode, planExitCode)}
+		})
+
+		exitCode := applyDriftResults(&report, results)
+
+		printRunResults("drift summary", "DIMENSIONS", results)
+
+		if reportJsonPath != "" {
+			if err := writeJsonFile(report, reportJsonPath); err != nil {
+				log.Fatalf("Failed to write JSON report: %v", err)
+			}
+			log.Println("drift JSON report written to: " + reportJsonPath)
+		}
+		if reportJunitPath != "" {
+			if err := writeDriftJunitReport(report, results, reportJunitPath); err != nil {
+				log.Fatalf("Failed to write JUnit report: %v", err)
+			}
+			log.Println("drift JUnit report written to: " + reportJunitPath)
+		}
+
+		os.Exit(exitCode)
+	},
+}
+
+// driftStatusByExitCodes returns status of the combination by exit codes of init and plan -detailed-exitcode,
+// plan is not executed after failed init. Failed init is always an error, even with exit code 2
+func driftStatusByExitCodes(initExitCode int, planExitCode int) string {
+	switch {
+	case initExitCode != 0:
+		return driftStatusError
+	case planExitCode == 0:
+		return driftStatusNoDrift
+	case planExitCode == 2:
+		return driftStatusDrift
+	}
+	return driftStatusError
+}
+
+// applyDriftResults copies results of the combinations into the report and returns exit code of the command:
+// 1 if any combination failed, 2 if drift detected, 0 otherwise
+func applyDriftResults(report *driftReport, results []runResult) int {
+	exitCode := 0
+	for i, result := range results {
+		report.Results[i].Status = result.Status
+		report.Results[i].ExitCode = result.ExitCode
+		report.Results[i].DurationSeconds = result.Duration.Seconds()
+
+		switch result.Status {
+		case driftStatusDrift:
+			if exitCode == 0 {
+				exitCode = 2
+			}
+		case driftStatusNoDrift:
+		default:
+			exitCode = 1
+		}
+	}
+	return exitCode
+}
+
+func writeDriftJunitReport(report driftReport, results []runResult, reportPath string) error {
+	suite := junitTestSuite{
+		Name:      "iacconsole drift " + report.Org + "/" + report.Unit,
+		Tests:     len(report.Results),
+		Timestamp: report.StartedAt.Format(time.RFC3339),
+	}
+
+	var totalDuration time.Duration
+	for i, entry := range report.Results {
+		totalDuration += results[i].Duration
+		testCase := junitTestCase{
+			ClassName: report.Org + "." + report.Unit,
+			Name:      results[i].Name,
+			Time:      fmt.Sprintf("%.3f", entry.DurationSeconds),
+			SystemOut: "state_path: " + entry.StatePath,
+		}
+
+		switch entry.Status {
+		case driftStatusDrift:
+			suite.Failures++
+			testCase.Failure = &junitMessage{Message: "drift detected in state " + entry.StatePath}
+		case driftStatusNoDrift:
+		default:
+			suite.Errors++
+			testCase.Error = &junitMessage{Message: fmt.Sprintf("drift check %s with exit code %d", entry.Status, entry.ExitCode)}
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	suite.Time = fmt.Sprintf("%.3f", totalDuration.Seconds())
+
+	reportBytes, err := xml.MarshalIndent(junitTestSuites{TestSuites: []junitTestSuite{suite}}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal xml: %v", err)
+	}
+	return os.WriteFile(reportPath, append([]byte(xml.Header), reportBytes...), 0644)
+}
+
+func init() {
+	rootCmd.AddCommand(driftCmd)
+
+	driftCmd.Flags().StringArrayP("dimension", "d", []string{}, "specify dimensions from invetory like dim:name, several values dim:name1,name2 are checked as matrix")
+	driftCmd.Flags().StringP("unit", "u", "", "specify unit")
+	driftCmd.Flags().StringP("org", "o", "", "specify org")
+	driftCmd.Flags().StringP("workspace", "w", "master", "specify workspace for IaCConsole DB")
+	driftCmd.Flags().String("matrix", "", "YAML/JSON file with dimension keys and lists of values to check every combination")
+	driftCmd.Flags().Int("parallelism", 1, "max number of dimension combinations checked in parallel")
+	driftCmd.Flags().String("report-json", "", "path to write JSON drift report to")
+	driftCmd.Flags().String("report-junit", "", "path to write JUnit XML drift report to")
+	if err := driftCmd.MarkFlagRequired("unit"); err != nil {
+		log.Fatalf("Error marking flag 'unit' as required: %v", err)
+	}
+	if err := driftCmd.MarkFlagRequired("org"); err != nil {
+		log.Fatalf("Error marking flag 'org' as required: %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDriftStatusByExitCodes(t *testing.T) {
+	tests := []struct {
+		name         string
+		initExitCode int
+		planExitCode int
+		want         string
+	}{
+		{name: "no changes", planExitCode: 0, want: driftStatusNoDrift},
+		{name: "changes", planExitCode: 2, want: driftStatusDrift},
+		{name: "plan failed", planExitCode: 1, want: driftStatusError},
+		{name: "plan interrupted", planExitCode: 130, want: driftStatusError},
+		{name: "init failed", initExitCode: 1, planExitCode: -1, want: driftStatusError},
+		{name: "init failed with exit code 2", initExitCode: 2, planExitCode: -1, want: driftStatusError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := driftStatusByExitCodes(tt.initExitCode, tt.planExitCode); got != tt.want {
+				t.Errorf("status = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDriftReports(t *testing.T) {
+	tests := []struct {
+		name         string
+		results      []runResult
+		wantExitCode int
+		wantStatuses []string
+		wantFailures int
+		wantErrors   int
+	}{
+		{
+			name:         "no drift",
+			results:      []runResult{{Name: "account:dev", Status: driftStatusNoDrift}, {Name: "account:prod", Status: driftStatusNoDrift}},
+			wantExitCode: 0,
+			wantStatuses: []string{driftStatusNoDrift, driftStatusNoDrift},
+		},
+		{
+			name:         "drift",
+			results:      []runResult{{Name: "account:dev", Status: driftStatusNoDrift}, {Name: "account:prod", Status: driftStatusDrift, ExitCode: 2}},
+			wantExitCode: 2,
+			wantStatuses: []string{driftStatusNoDrift, driftStatusDrift},
+			wantFailures: 1,
+		},
+		{
+			name: "error beats drift in any order",
+			results: []runResult{
+				{Name: "account:dev", Status: driftStatusError, ExitCode: 2},
+				{Name: "account:prod", Status: driftStatusDrift, ExitCode: 2},
+				{Name: "account:test", Status: "skipped", ExitCode: -1},
+			},
+			wantExitCode: 1,
+			wantStatuses: []string{driftStatusError, driftStatusDrift, "skipped"},
+			wantFailures: 1,
+			wantErrors:   2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := driftReport{Org: "demo-org", Unit: "vpc", StartedAt: time.Now().UTC(), Results: make([]driftReportEntry, len(tt.results))}
+			for i := range tt.results {
+				tt.results[i].Duration = time.Second
+				report.Results[i].StatePath = "org_demo-org/" + tt.results[i].Name + "/vpc.tfstate"
+			}
+
+			if exitCode := applyDriftResults(&report, tt.results); exitCode != tt.wantExitCode {
+				t.Errorf("exit code = %d, want %d", exitCode, tt.wantExitCode)
+			}
+
+			reportJsonPath := filepath.Join(t.TempDir(), "drift.json")
+			if err := writeJsonFile(report, reportJsonPath); err != nil {
+				t.Fatal(err)
+			}
+			reportBytes, err := os.ReadFile(reportJsonPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var jsonReport driftReport
+			if err := json.Unmarshal(reportBytes, &jsonReport); err != nil {
+				t.Fatal(err)
+			}
+			var statuses []string
+			for i, entry := range jsonReport.Results {
+				statuses = append(statuses, entry.Status)
+				if entry.ExitCode != tt.results[i].ExitCode || entry.DurationSeconds != 1 {
+					t.Errorf("entry %d: exit code %d and duration %v, want %d and 1", i, entry.ExitCode, entry.DurationSeconds, tt.results[i].ExitCode)
+				}
+			}
+			if !reflect.DeepEqual(statuses, tt.wantStatuses) {
+				t.Errorf("statuses = %v, want %v", statuses, tt.wantStatuses)
+			}
+
+			reportJunitPath := filepath.Join(t.TempDir(), "drift.xml")
+			if err := writeDriftJunitReport(report, tt.results, reportJunitPath); err != nil {
+				t.Fatal(err)
+			}
+			junitBytes, err := os.ReadFile(reportJunitPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var junitReport junitTestSuites
+			if err := xml.Unmarshal(junitBytes, &junitReport); err != nil {
+				t.Fatal(err)
+			}
+			suite := junitReport.TestSuites[0]
+			if suite.Tests != len(tt.results) || suite.Failures != tt.wantFailures || suite.Errors != tt.wantErrors {
+				t.Errorf("tests %d, failures %d, errors %d, want %d, %d, %d", suite.Tests, suite.Failures, suite.Errors, len(tt.results), tt.wantFailures, tt.wantErrors)
+			}
+			for i, testCase := range suite.TestCases {
+				if testCase.Name != tt.results[i].Name || (testCase.Failure != nil) != (tt.wantStatuses[i] == driftStatusDrift) {
+					t.Errorf("test case %d: %+v for status %s", i, testCase, tt.wantStatuses[i])
+				}
+			}
+		})
+	}
+}
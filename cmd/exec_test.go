@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestGetDimCombinationsByUnit(t *testing.T) {
+	unitsPath := t.TempDir()
+	manifests := map[string]string{
+		"network": `{"dimensions": ["account", {"name": "region", "multi": true}]}`,
+		"vpc":     `{"dimensions": ["account", "region"]}`,
+	}
+	for unitName, manifest := range manifests {
+		if err := os.MkdirAll(filepath.Join(unitsPath, "matrix-org", unitName), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(unitsPath, "matrix-org", unitName, "unit_manifest.json"), []byte(manifest), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	viper.Set("matrix-org.units_path", unitsPath)
+	matrixPath := filepath.Join(t.TempDir(), "matrix.yaml")
+	if err := os.WriteFile(matrixPath, []byte("region: [us]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		unit   string
+		args   []string
+		matrix string
+		want   [][]string
+	}{
+		{
+			name: "multi dimension is kept together",
+			unit: "network",
+			args: []string{"account:a", "account:b", "region:eu", "region:us"},
+			want: [][]string{{"account:a", "region:eu", "region:us"}, {"account:b", "region:eu", "region:us"}},
+		},
+		{
+			name: "the same args are expanded for unit without multi dimensions",
+			unit: "vpc",
+			args: []string{"account:a", "region:eu", "region:us"},
+			want: [][]string{{"account:a", "region:eu"}, {"account:a", "region:us"}},
+		},
+		{
+			name:   "matrix file values of multi dimension",
+			unit:   "network",
+			args:   []string{"account:a", "region:eu"},
+			matrix: matrixPath,
+			want:   [][]string{{"account:a", "region:eu", "region:us"}},
+		},
+	}
+
+	// exec, render and drift share the flags used by getDimCombinations
+	for _, command := range []string{"exec", "render", "drift"} {
+		for _, tt := range tests {
+			t.Run(command+"/"+tt.name, func(t *testing.T) {
+				cmd, _, err := rootCmd.Find([]string{command})
+				if err != nil {
+					t.Fatal(err)
+				}
+				cmd.Flags().Set("org", "matrix-org")
+				cmd.Flags().Set("unit", tt.unit)
+				dimensionFlag := cmd.Flags().Lookup("dimension")
+				dimensionFlag.Value.(interface{ Replace([]string) error }).Replace(tt.args)
+				if cmd.Flags().Lookup("matrix") != nil {
+					cmd.Flags().Set("matrix", tt.matrix)
+				} else if tt.matrix != "" {
+					t.Skip(command + " has no --matrix")
+				}
+
+				got, err := getDimCombinations(cmd)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("combinations = %v, want %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+// TestExecResultJsonOnSetupFailure runs exec in a subprocess, as the command exits with log.Fatalf
+func TestExecResultJsonOnSetupFailure(t *testing.T) {
+	if args := os.Getenv("IACCONSOLE_TEST_ARGS"); args != "" {
+		rootCmd.SetArgs(strings.Fields(args))
+		Execute()
+		os.Exit(0)
+	}
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "units", "demo-org", "vpc"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "units", "demo-org", "vpc", "unit_manifest.json"), []byte(`{"dimensions": ["account"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte("defaults:\n  units_path: "+dir+"/units\n  inventory_path: "+dir+"/inventory\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	invalidMatrixPath := filepath.Join(dir, "matrix.yaml")
+	if err := os.WriteFile(invalidMatrixPath, []byte("account: [dev"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		args    string
+		wantErr string
+	}{
+		{name: "invalid -d", args: "-d account", wantErr: "invalid dimension format: account"},
+		{name: "dim_ value", args: "-d account:dim_defaults", wantErr: "with dim_ prefix can't be passed"},
+		{name: "missing matrix file", args: "-d account:dev --matrix " + filepath.Join(dir, "missing.yaml"), wantErr: "failed to read dimension matrix"},
+		{name: "invalid matrix file", args: "-d account:dev --matrix " + invalidMatrixPath, wantErr: "failed to read dimension matrix"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resultJsonPath := filepath.Join(t.TempDir(), "result.json")
+			cmd := exec.Command(os.Args[0], "-test.run=^TestExecResultJsonOnSetupFailure$")
+			cmd.Env = append(os.Environ(),
+				"IACCONSOLE_TEST_ARGS=exec --config "+configPath+" -o demo-org -u vpc --result-json "+resultJsonPath+" "+tt.args+" -- plan")
+			output, err := cmd.CombinedOutput()
+			var exitErr *exec.ExitError
+			if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+				t.Fatalf("expected exit code 1, got %v, output:\n%s", err, output)
+			}
+
+			resultBytes, err := os.ReadFile(resultJsonPath)
+			if err != nil {
+				t.Fatalf("result json is not written: %v, output:\n%s", err, output)
+			}
+			var result execResult
+			if err := json.Unmarshal(resultBytes, &result); err != nil {
+				t.Fatal(err)
+			}
+			if result.ExitCode != 1 || !strings.Contains(result.Error, tt.wantErr) {
+				t.Errorf("exit code %d and error %q, want 1 and error containing %q", result.ExitCode, result.Error, tt.wantErr)
+			}
+			if result.Org != "demo-org" || result.Unit != "vpc" {
+				t.Errorf("org %s and unit %s are not in the result", result.Org, result.Unit)
+			}
+		})
+	}
+}
@@ -0,0 +1,317 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alt-dima/iacconsole-cli/utils"
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+)
+
+// inventoryDiffEntry is one difference of dimension data between two inventory sources
+type inventoryDiffEntry struct {
+	Value  string      `json:"value" yaml:"value"`
+	Path   string      `json:"path" yaml:"path"`
+	Change string      `json:"change" yaml:"change"`
+	Left   interface{} `json:"left,omitempty" yaml:"left,omitempty"`
+	Right  interface{} `json:"right,omitempty" yaml:"right,omitempty"`
+}
+
+// inventoryCmd represents the inventory command group
+var inventoryCmd = &cobra.Command{
+	Use:   "inventory",
+	Short: "Explore inventory of the org in Inventory Files or IaCConsole API",
+	Long: `Lists, shows and compares dimensions from the inventory provider of the org (files or api).
+Provider could be overridden with --provider, output format is selected with --format table|json|yaml`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		initConfig()
+		format, _ := cmd.Flags().GetString("format")
+		if !slices.Contains([]string{"table", "json", "yaml"}, format) {
+			log.Fatalf("unsupported --format %q, expected table, json or yaml", format)
+		}
+	},
+}
+
+var inventoryLsCmd = &cobra.Command{
+	Use:   "ls <org> [dimension]",
+	Short: "List dimensions of the org or values of the dimension",
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		workspace, _ := cmd.Flags().GetString("workspace")
+		providerName, _ := cmd.Flags().GetString("provider")
+		s, err := newInventoryState(args[0], workspace, providerName)
+		if err != nil {
+			log.Fatalf("Configuration error: %v", err)
+		}
+		provider, err := s.InventoryProvider()
+		if err != nil {
+			log.Fatalf("Failed to load inventory: %v", err)
+		}
+
+		header := "DIMENSION"
+		var names []string
+		if len(args) == 2 {
+			header = "VALUE"
+			names, err = provider.ListDimension(args[1])
+		} else if lister, ok := provider.(utils.InventoryDimensionsLister); ok {
+			names, err = lister.ListDimensions()
+		} else {
+			err = fmt.Errorf("inventory provider %s can not list dimensions, specify the dimension", s.InventoryProviderName())
+		}
+		if err != nil {
+			log.Fatalf("Failed to list inventory: %v", err)
+		}
+
+		rows := make([][]string, 0, len(names))
+		for _, name := range names {
+			rows = append(rows, []string{name})
+		}
+		printInventory(cmd, names, []string{header}, rows)
+	},
+}
+
+var inventoryShowCmd = &cobra.Command{
+	Use:   "show <org> <dimension> <value>",
+	Short: "Show data of the dimension value, with $extends resolved",
+	Args:  cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		workspace, _ := cmd.Flags().GetString("workspace")
+		providerName, _ := cmd.Flags().GetString("provider")
+		s, err := newInventoryState(args[0], workspace, providerName)
+		if err != nil {
+			log.Fatalf("Configuration error: %v", err)
+		}
+		dimensionJsonMap, err := s.GetDimData(args[1], args[2], false)
+		if err != nil {
+			log.Fatalf("Failed to load dimension: %v", err)
+		}
+
+		flatData := make(map[string]interface{})
+		flattenDimData("", dimensionJsonMap, flatData)
+		rows := make([][]string, 0, len(flatData))
+		for _, path := range sortedKeys(flatData) {
+			rows = append(rows, []string{path, formatInventoryValue(flatData[path])})
+		}
+		printInventory(cmd, dimensionJsonMap, []string{"KEY", "VALUE"}, rows)
+	},
+}
+
+var inventoryDiffCmd = &cobra.Command{
+	Use:   "diff <org> <dimension> [value]",
+	Short: "Compare dimension values between two workspaces or two providers",
+	Long: `Compares data of the dimension value (or all the values of the dimension) from --workspace and --provider
+with --against-workspace and --against-provider, like master workspace against a feature one or files against api.
+Exit code is 2 if differences found`,
+	Args: cobra.RangeArgs(2, 3),
+	Run: func(cmd *cobra.Command, args []string) {
+		workspace, _ := cmd.Flags().GetString("workspace")
+		providerName, _ := cmd.Flags().GetString("provider")
+		againstWorkspace, _ := cmd.Flags().GetString("against-workspace")
+		againstProviderName, _ := cmd.Flags().GetString("against-provider")
+		if againstWorkspace == "" {
+			againstWorkspace = workspace
+		}
+		if againstProviderName == "" {
+			againstProviderName = providerName
+		}
+
+		left, err := newInventoryState(args[0], workspace, providerName)
+		if err != nil {
+			log.Fatalf("Configuration error: %v", err)
+		}
+		right, err := newInventoryState(args[0], againstWorkspace, againstProviderName)
+		if err != nil {
+			log.Fatalf("Configuration error: %v", err)
+		}
+		if left.InventoryProviderName() == right.InventoryProviderName() && workspace == againstWorkspace {
+			log.Fatalf("nothing to compare, set --against-workspace or --against-provider")
+		}
+		log.Printf("comparing %s@%s with %s@%s", left.InventoryProviderName(), workspace, right.InventoryProviderName(), againstWorkspace)
+
+		dimensionKey := args[1]
+		dimensionValues := args[2:]
+		if len(dimensionValues) == 0 {
+			for _, s := range []*utils.State{left, right} {
+				provider, err := s.InventoryProvider()
+				if err != nil {
+					log.Fatalf("Failed to load inventory: %v", err)
+				}
+				values, err := provider.ListDimension(dimensionKey)
+				if err != nil {
+					log.Fatalf("Failed to list dimension %s: %v", dimensionKey, err)
+				}
+				for _, value := range values {
+					if !slices.Contains(dimensionValues, value) {
+						dimensionValues = append(dimensionValues, value)
+					}
+				}
+			}
+			sort.Strings(dimensionValues)
+		}
+
+		diffEntries := []inventoryDiffEntry{}
+		for _, dimensionValue := range dimensionValues {
+			leftData, err := left.GetDimData(dimensionKey, dimensionValue, true)
+			if err != nil {
+				log.Fatalf("Failed to load dimension: %v", err)
+			}
+			rightData, err := right.GetDimData(dimensionKey, dimensionValue, true)
+			if err != nil {
+				log.Fatalf("Failed to load dimension: %v", err)
+			}
+			diffEntries = append(diffEntries, diffDimData(dimensionValue, leftData, rightData)...)
+		}
+
+		rows := make([][]string, 0, len(diffEntries))
+		for _, entry := range diffEntries {
+			rows = append(rows, []string{entry.Value, entry.Path, entry.Change, formatInventoryValue(entry.Left), formatInventoryValue(entry.Right)})
+		}
+		printInventory(cmd, diffEntries, []string{"VALUE", "PATH", "CHANGE", "LEFT", "RIGHT"}, rows)
+
+		if len(diffEntries) > 0 {
+			os.Exit(2)
+		}
+	},
+}
+
+// newInventoryState creates State to work with the inventory of the org
+func newInventoryState(orgName string, workspace string, providerName string) (*utils.State, error) {
+	apiEndpoint, err := getApiEndpoint()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &utils.State{OrgName: orgName, Workspace: workspace, ApiEndpoint: apiEndpoint, InventorySource: providerName, Verbose: Verbose, Offline: Offline}
+	if s.GetStringFromViperByOrgOrDefault("inventory_path") != "" {
+		s.InventoryPath, _ = filepath.Abs(s.GetStringFromViperByOrgOrDefault("inventory_path") + "/" + s.OrgName)
+	}
+	return s, nil
+}
+
+// diffDimData returns differences of flattened data, missing value on one side is reported as a single entry
+func diffDimData(dimensionValue string, leftData map[string]interface{}, rightData map[string]interface{}) []inventoryDiffEntry {
+	switch {
+	case leftData == nil && rightData == nil:
+		return nil
+	case leftData == nil:
+		return []inventoryDiffEntry{{Value: dimensionValue, Path: "/", Change: "added", Right: rightData}}
+	case rightData == nil:
+		return []inventoryDiffEntry{{Value: dimensionValue, Path: "/", Change: "removed", Left: leftData}}
+	}
+
+	leftFlat := make(map[string]interface{})
+	rightFlat := make(map[string]interface{})
+	flattenDimData("", leftData, leftFlat)
+	flattenDimData("", rightData, rightFlat)
+
+	paths := sortedKeys(leftFlat)
+	for path := range rightFlat {
+		if _, ok := leftFlat[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var diffEntries []inventoryDiffEntry
+	for _, path := range paths {
+		leftValue, inLeft := leftFlat[path]
+		rightValue, inRight := rightFlat[path]
+		switch {
+		case !inLeft:
+			diffEntries = append(diffEntries, inventoryDiffEntry{Value: dimensionValue, Path: path, Change: "added", Right: rightValue})
+		case !inRight:
+			diffEntries = append(diffEntries, inventoryDiffEntry{Value: dimensionValue, Path: path, Change: "removed", Left: leftValue})
+		case formatInventoryValue(leftValue) != formatInventoryValue(rightValue):
+			diffEntries = append(diffEntries, inventoryDiffEntry{Value: dimensionValue, Path: path, Change: "changed", Left: leftValue, Right: rightValue})
+		}
+	}
+	return diffEntries
+}
+
+// flattenDimData collects leaf values of the data by paths like /vpc/cidr, empty objects and lists are leaves too
+func flattenDimData(path string, value interface{}, flatData map[string]interface{}) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if len(value) == 0 && path != "" {
+			flatData[path] = value
+		}
+		for key, child := range value {
+			flattenDimData(path+"/"+key, child, flatData)
+		}
+	case []interface{}:
+		if len(value) == 0 {
+			flatData[path] = value
+		}
+		for i, child := range value {
+			flattenDimData(fmt.Sprintf("%s/%d", path, i), child, flatData)
+		}
+	default:
+		flatData[path] = value
+	}
+}
+
+func sortedKeys(flatData map[string]interface{}) []string {
+	keys := make([]string, 0, len(flatData))
+	for key := range flatData {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatInventoryValue formats value for the table, strings as is and everything else as JSON
+func formatInventoryValue(value interface{}) string {
+	if value, ok := value.(string); ok {
+		return value
+	}
+	if value == nil {
+		return ""
+	}
+	valueBytes, _ := json.Marshal(value)
+	return string(valueBytes)
+}
+
+// printInventory prints document as JSON or YAML, or rows as table, depending on --format
+func printInventory(cmd *cobra.Command, document interface{}, header []string, rows [][]string) {
+	format, _ := cmd.Flags().GetString("format")
+	switch format {
+	case "json":
+		documentBytes, err := json.MarshalIndent(document, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to marshal json: %v", err)
+		}
+		fmt.Println(string(documentBytes))
+	case "yaml":
+		documentBytes, err := yaml.Marshal(document)
+		if err != nil {
+			log.Fatalf("Failed to marshal yaml: %v", err)
+		}
+		fmt.Print(string(documentBytes))
+	default:
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, strings.Join(header, "\t"))
+		for _, row := range rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+		w.Flush()
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(inventoryCmd)
+	inventoryCmd.AddCommand(inventoryLsCmd, inventoryShowCmd, inventoryDiffCmd)
+
+	inventoryCmd.PersistentFlags().StringP("workspace", "w", "master", "specify workspace for IaCConsole DB")
+	inventoryCmd.PersistentFlags().String("provider", "", "inventory provider (files, api), default is inventory_provider of the org")
+	inventoryCmd.PersistentFlags().String("format", "table", "output format: table, json or yaml")
+	inventoryDiffCmd.Flags().String("against-workspace", "", "workspace to compare with, default is --workspace")
+	inventoryDiffCmd.Flags().String("against-provider", "", "inventory provider to compare with, default is --provider")
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/spf13/cobra"
+)
+
+var inventoryPullCmd = &cobra.Command{
+	Use:   "pull",
+	Short: "Export inventory of the org from IaCConsole API to Inventory Files",
+	Long: `Writes all the values of all the dimensions (or only --dimension ones) of the org from the workspace in IaCConsole API
+to <out>/<org>/<dimension>/<value>.json, the same layout as inventory_path. JSON is pretty-printed with sorted keys.
+With --prune JSON files of values missing in the API are removed (dim_ files are kept).
+With --record-workspaces the workspace every value came from (master if fallback happened) is written to <out>/<org>/.iacconsole_workspaces.json`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		orgName, _ := cmd.Flags().GetString("org")
+		workspace, _ := cmd.Flags().GetString("workspace")
+		outDir, _ := cmd.Flags().GetString("out")
+		dimensionKeys, _ := cmd.Flags().GetStringSlice("dimension")
+		recordWorkspaces, _ := cmd.Flags().GetBool("record-workspaces")
+		prune, _ := cmd.Flags().GetBool("prune")
+
+		s, err := newInventoryState(orgName, workspace, "")
+		if err != nil {
+			log.Fatalf("Configuration error: %v", err)
+		}
+
+		valueWorkspaces, err := s.PullInventory(outDir, dimensionKeys, prune)
+		if err != nil {
+			log.Fatalf("Failed to pull inventory: %v", err)
+		}
+		if recordWorkspaces {
+			if err := s.WriteInventoryWorkspaces(outDir, valueWorkspaces); err != nil {
+				log.Fatalf("Failed to write workspaces: %v", err)
+			}
+		}
+		log.Printf("pulled %d values of %s from workspace %s to %s", len(valueWorkspaces), orgName, workspace, outDir)
+	},
+}
+
+func init() {
+	inventoryCmd.AddCommand(inventoryPullCmd)
+
+	inventoryPullCmd.Flags().StringP("org", "o", "", "specify org")
+	inventoryPullCmd.Flags().String("out", "", "directory to write <org>/<dimension>/<value>.json to")
+	inventoryPullCmd.Flags().StringSliceP("dimension", "d", []string{}, "pull only these dimensions, all dimensions of the org by default")
+	inventoryPullCmd.Flags().Bool("prune", false, "remove <value>.json files of values missing in the workspace")
+	inventoryPullCmd.Flags().Bool("record-workspaces", false, "write the workspace every value came from to <out>/<org>/.iacconsole_workspaces.json")
+	for _, flagName := range []string{"org", "out"} {
+		if err := inventoryPullCmd.MarkFlagRequired(flagName); err != nil {
+			log.Fatalf("Error marking flag '%s' as required: %v", flagName, err)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var inventoryPushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Upload Inventory Files of the org to the workspace in IaCConsole API",
+	Long: `Compares every dimension value (including dim_defaults and other dim_ files) in Inventory Files of the org
+with the workspace in IaCConsole API and uploads new and changed ones.
+With --prune values missing in the files are deleted from the workspace, with --dry-run changes are only shown.
+Exit code is 1 if any change failed`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		orgName, _ := cmd.Flags().GetString("org")
+		workspace, _ := cmd.Flags().GetString("workspace")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		prune, _ := cmd.Flags().GetBool("prune")
+
+		s, err := newInventoryState(orgName, workspace, "")
+		if err != nil {
+			log.Fatalf("Configuration error: %v", err)
+		}
+		if dryRun {
+			log.Printf("dry run, changes of %s inventory in workspace %s are not applied", orgName, workspace)
+		}
+
+		changes, err := s.PushInventory(dryRun, prune)
+		if err != nil {
+			log.Fatalf("Failed to push inventory: %v", err)
+		}
+
+		failed := 0
+		rows := make([][]string, 0, len(changes))
+		for _, change := range changes {
+			status := "done"
+			switch {
+			case change.Error != "":
+				status = "failed: " + change.Error
+				failed++
+			case dryRun:
+				status = "planned"
+			}
+			rows = append(rows, []string{change.Dimension, change.Value, change.Change, status})
+		}
+		printInventory(cmd, changes, []string{"DIMENSION", "VALUE", "CHANGE", "STATUS"}, rows)
+		log.Printf("%d changes, %d failed", len(changes), failed)
+
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	inventoryCmd.AddCommand(inventoryPushCmd)
+
+	inventoryPushCmd.Flags().StringP("org", "o", "", "specify org")
+	inventoryPushCmd.Flags().Bool("dry-run", false, "only show changes without uploading")
+	inventoryPushCmd.Flags().Bool("prune", false, "delete values missing in Inventory Files from the workspace")
+	if err := inventoryPushCmd.MarkFlagRequired("org"); err != nil {
+		log.Fatalf("Error marking flag 'org' as required: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestInventoryPushExitCode runs inventory push in a subprocess, as the command exits with os.Exit
+func TestInventoryPushExitCode(t *testing.T) {
+	if args := os.Getenv("IACCONSOLE_TEST_ARGS"); args != "" {
+		rootCmd.SetArgs(strings.Fields(args))
+		Execute()
+		os.Exit(0)
+	}
+
+	// the workspace is empty and uploads of value "bad" are rejected
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet:
+			http.NotFound(w, r)
+		case strings.HasSuffix(r.URL.Path, "/bad"):
+			http.Error(w, "rejected", http.StatusBadRequest)
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	config := "defaults:\n  inventory_path: " + dir + "/inventory\n  api_cache_dir: " + dir + "/cache\n  api_retries: 0\n"
+	if err := os.WriteFile(configPath, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		values   []string
+		args     string
+		wantCode int
+	}{
+		{name: "all changes applied", values: []string{"good"}, wantCode: 0},
+		{name: "partial failure", values: []string{"good", "bad"}, wantCode: 1},
+		{name: "dry run does not upload", values: []string{"good", "bad"}, args: "--dry-run", wantCode: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inventoryPath := filepath.Join(dir, "inventory", "demo-org", "account")
+			if err := os.RemoveAll(inventoryPath); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.MkdirAll(inventoryPath, 0755); err != nil {
+				t.Fatal(err)
+			}
+			for _, value := range tt.values {
+				if err := os.WriteFile(filepath.Join(inventoryPath, value+".json"), []byte(`{"id": 1}`), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cmd := exec.Command(os.Args[0], "-test.run=^TestInventoryPushExitCode$")
+			cmd.Env = append(os.Environ(),
+				"IACCONSOLE_TEST_ARGS=inventory push --org demo-org --config "+configPath+" "+tt.args,
+				"IACCONSOLE_API_URL="+server.URL)
+			output, err := cmd.CombinedOutput()
+
+			code := 0
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				code = exitErr.ExitCode()
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if code != tt.wantCode {
+				t.Errorf("exit code = %d, want %d, output:\n%s", code, tt.wantCode, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/alt-dima/iacconsole-cli/utils"
+	"github.com/spf13/cobra"
+)
+
+// linePrefixWriter writes every complete line to the underlying writer with prefix, lines of parallel children are not mixed
+type linePrefixWriter struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	prefix string
+	buf    bytes.Buffer
+}
+
+func (w *linePrefixWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+	for {
+		line, err := w.buf.ReadBytes('\n')
+		if err != nil {
+			// incomplete line, keep it for the next write
+			w.buf.Write(line)
+			return len(p), nil
+		}
+		w.mu.Lock()
+		_, err = w.out.Write(append([]byte(w.prefix), line...))
+		w.mu.Unlock()
+		if err != nil {
+			return len(p), err
+		}
+	}
+}
+
+// Flush writes the rest of the buffered output without trailing new line
+func (w *linePrefixWriter) Flush() {
+	if w.buf.Len() > 0 {
+		w.Write([]byte("\n"))
+	}
+}
+
+// runDimMatrix executes exec command for every dimension combination in a child process, at most parallelism at once
+func runDimMatrix(cmd *cobra.Command, args []string, dimCombinations [][]string) {
+	unitName, _ := cmd.Flags().GetString("unit")
+	parallelism, _ := cmd.Flags().GetInt("parallelism")
+
+	log.Printf("executing unit %s for %d dimension combinations with parallelism %d", unitName, len(dimCombinations), parallelism)
+
+	// every child writes its own result json, they are collected into one list at the end
+	resultJsonPath, _ := cmd.Flags().GetString("result-json")
+	var childResultsDir string
+	if resultJsonPath != "" {
+		var err error
+		childResultsDir, err = os.MkdirTemp("", "iacconsole-results-")
+		if err != nil {
+			log.Fatalf("Failed to create temp dir for results: %v", err)
+		}
+	}
+	childResultJsonPath := func(dimCombination []string) string {
+		return filepath.Join(childResultsDir, utils.GetMD5Hash(strings.Join(dimCombination, " "))+".json")
+	}
+
+	results := runDimCombinations(dimCombinations, parallelism, func(dimCombination []string, stdout io.Writer, stderr io.Writer, sigs <-chan os.Signal) runResult {
+		result := runResult{ExitCode: 1, Status: "failed"}
+
+		execFlags := getExecFlags(cmd, dimCombination)
+		if childResultsDir != "" {
+			execFlags = append(execFlags, "--result-json", childResultJsonPath(dimCombination))
+		}
+		childCommand, err := newSelfExecCommand(execFlags, args)
+		if err != nil {
+			log.Printf("Failed to prepare child command: %v", err)
+			return result
+		}
+		childCommand.Stdout = stdout
+		childCommand.Stderr = stderr
+
+		result.ExitCode = runChildCommand(childCommand, sigs)
+		if result.ExitCode == 0 {
+			result.Status = "success"
+		}
+		return result
+	})
+
+	printRunResults("dimension matrix summary", "DIMENSIONS", results)
+
+	exitCode := 0
+	for _, result := range results {
+		if result.Status != "success" {
+			exitCode = 1
+		}
+	}
+
+	if resultJsonPath != "" {
+		execResults := make([]execResult, 0, len(dimCombinations))
+		for i, dimCombination := range dimCombinations {
+			var childResult execResult
+			childResultBytes, err := os.ReadFile(childResultJsonPath(dimCombination))
+			if err == nil {
+				err = json.Unmarshal(childResultBytes, &childResult)
+			}
+			if err != nil {
+				// child was not started or failed before writing its result
+				childResult = execResult{Dimensions: dimArgsToMap(dimCombination), ExitCode: results[i].ExitCode, Error: results[i].Status}
+				childResult.Org, _ = cmd.Flags().GetString("org")
+				childResult.Unit, _ = cmd.Flags().GetString("unit")
+				childResult.Workspace, _ = cmd.Flags().GetString("workspace")
+			}
+			execResults = append(execResults, childResult)
+		}
+		if err := writeJsonFile(execResults, resultJsonPath); err != nil {
+			log.Printf("Failed to write result json: %v", err)
+		}
+	}
+
+	if childResultsDir != "" {
+		os.RemoveAll(childResultsDir)
+	}
+	os.Exit(exitCode)
+}
+
+// getExecFlags returns exec flags for the child process with org, unit, workspace, clean and fail-on-lock from cmd and given dimensions
+func getExecFlags(cmd *cobra.Command, dimCombination []string) []string {
+	unitName, _ := cmd.Flags().GetString("unit")
+	orgName, _ := cmd.Flags().GetString("org")
+	workspace, _ := cmd.Flags().GetString("workspace")
+	forceCleanTempDir, _ := cmd.Flags().GetBool("clean")
+
+	execFlags := []string{"--org", orgName, "--unit", unitName, "--workspace", workspace}
+	for _, dimension := range dimCombination {
+		execFlags = append(execFlags, "--dimension", dimension)
+	}
+	if forceCleanTempDir {
+		execFlags = append(execFlags, "--clean")
+	}
+	if failOnLock, _ := cmd.Flags().GetBool("fail-on-lock"); failOnLock {
+		execFlags = append(execFlags, "--fail-on-lock")
+	}
+	return execFlags
+}
+
+// combinationRunner executes one dimension combination with given output writers, passing sigs to children
+type combinationRunner func(dimCombination []string, stdout io.Writer, stderr io.Writer, sigs <-chan os.Signal) runResult
+
+// runDimCombinations calls runner for every dimension combination, at most parallelism at once.
+// Output of every combination is prefixed with its dimensions, combinations not started before interrupt are skipped
+func runDimCombinations(dimCombinations [][]string, parallelism int, runner combinationRunner) []runResult {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
+	defer signal.Stop(interrupts)
+	interrupted := make(chan struct{})
+	go func() {
+		<-interrupts
+		close(interrupted)
+	}()
+
+	var outputMu sync.Mutex
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, parallelism)
+	results := make([]runResult, len(dimCombinations))
+
+	for i, dimCombination := range dimCombinations {
+		combinationName := strings.Join(dimCombination, " ")
+		results[i] = runResult{Name: combinationName, Status: "skipped", ExitCode: -1}
+
+		select {
+		case semaphore <- struct{}{}:
+		case <-interrupted:
+		}
+		select {
+		case <-interrupted:
+			log.Printf("interrupted, skipping %s", combinationName)
+			continue
+		default:
+		}
+
+		wg.Add(1)
+		go func(i int, dimCombination []string) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			childSigs := make(chan os.Signal, 2)
+			signal.Notify(childSigs, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
+			defer signal.Stop(childSigs)
+
+			prefix := "[" + combinationName + "] "
+			stdout := &linePrefixWriter{mu: &outputMu, out: os.Stdout, prefix: prefix}
+			stderr := &linePrefixWriter{mu: &outputMu, out: os.Stderr, prefix: prefix}
+
+			startTime := time.Now()
+			result := runner(dimCombination, stdout, stderr, childSigs)
+			stdout.Flush()
+			stderr.Flush()
+
+			result.Name = combinationName
+			result.Duration = time.Since(startTime).Round(time.Second)
+			results[i] = result
+		}(i, dimCombination)
+	}
+	wg.Wait()
+
+	return results
+}
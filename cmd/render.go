@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alt-dima/iacconsole-cli/utils"
+	"github.com/otiai10/copy"
+	"github.com/spf13/cobra"
+)
+
+const renderMetadataFileName = "iacconsole_render.json"
+
+// renderMetadata describes the target of the rendered unit
+type renderMetadata struct {
+	Org        string            `json:"org"`
+	Unit       string            `json:"unit"`
+	Dimensions map[string]string `json:"dimensions"`
+	Workspace  string            `json:"workspace"`
+	StatePath  string            `json:"state_path"`
+}
+
+// renderCmd represents the render command
+var renderCmd = &cobra.Command{
+	Use:   "render",
+	Short: "Render synthesized unit directory without executing OpenTofu",
+	Long: `Prepares the unit with all the generated vars like exec does, but instead of executing OpenTofu
+copies the synthesized directory to --out or prints files and generated tfvars with --print`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		initConfig()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		outDir, _ := cmd.Flags().GetString("out")
+		printRendered, _ := cmd.Flags().GetBool("print")
+		if outDir == "" && !printRendered {
+			log.Fatalf("Error: --out or --print must be set")
+		}
+
+		dimCombinations, err := getDimCombinations(cmd)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		if len(dimCombinations) > 1 {
+			log.Fatalf("Error: render supports only one value per dimension")
+		}
+
+		if outDir != "" {
+			if err := prepareRenderOutDir(outDir); err != nil {
+				log.Fatalf("Error: %v", err)
+			}
+		}
+
+		s, err := newStateFromFlags(cmd, dimCombinations[0])
+		if err != nil {
+			log.Fatalf("Configuration error: %v", err)
+		}
+		// render uses its own temp dir removed at the end, so the temp dir of exec (with .terraform and plans) is not touched
+		s.SetTempDirPurpose("render")
+		removeRenderTemp := func() {
+			os.RemoveAll(s.CmdWorkTempDir)
+			s.UnlockTemp()
+		}
+		// render never executes OpenTofu, so states of inputs_from units are not read
+		if _, err := prepareUnit(s, false); err != nil {
+			removeRenderTemp()
+			log.Fatalf("Failed to prepare unit: %v", err)
+		}
+
+		metadata := renderMetadata{
+			Org:        s.OrgName,
+			Unit:       s.UnitName,
+			Dimensions: s.ParsedDimensions,
+			Workspace:  s.Workspace,
+			StatePath:  s.StateS3Path,
+		}
+		metadataBytes, err := json.MarshalIndent(metadata, "", "  ")
+		if err != nil {
+			removeRenderTemp()
+			log.Fatalf("Failed to marshal render metadata: %v", err)
+		}
+
+		if outDir != "" {
+			opt := copy.Options{
+				OnSymlink: func(src string) copy.SymlinkAction {
+					return copy.Deep
+				},
+				Skip: func(info os.FileInfo, src string, dest string) (bool, error) {
+					return info.Name() == ".terraform" || utils.IsTempDirInternalFile(info.Name()), nil
+				},
+			}
+			// permissions of the temp dir are preserved, generated tfvars with secrets stay readable only by the user
+			if err := copy.Copy(s.CmdWorkTempDir, outDir, opt); err != nil {
+				removeRenderTemp()
+				log.Fatalf("Failed to copy rendered unit to %s: %v", outDir, err)
+			}
+			if err := os.Chmod(outDir, 0700); err != nil {
+				removeRenderTemp()
+				log.Fatalf("Failed to restrict permissions of %s: %v", outDir, err)
+			}
+			if err := os.WriteFile(filepath.Join(outDir, renderMetadataFileName), metadataBytes, 0644); err != nil {
+				removeRenderTemp()
+				log.Fatalf("Failed to write render metadata: %v", err)
+			}
+			log.Println("rendered unit to: " + outDir)
+		}
+
+		if printRendered {
+			if err := printRenderedDir(s.CmdWorkTempDir, s.SensitiveValues); err != nil {
+				removeRenderTemp()
+				log.Fatalf("Failed to print rendered unit: %v", err)
+			}
+			fmt.Println("\n# " + renderMetadataFileName)
+			fmt.Println(string(metadataBytes))
+		}
+		removeRenderTemp()
+	},
+}
+
+// prepareRenderOutDir allows only empty or missing --out, or the one with previous render which is cleared,
+// so files removed from the unit do not stay in the rendered directory
+func prepareRenderOutDir(outDir string) error {
+	entries, err := os.ReadDir(outDir)
+	if os.IsNotExist(err) || (err == nil && len(entries) == 0) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read --out %s: %v", outDir, err)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, renderMetadataFileName)); err != nil {
+		return fmt.Errorf("--out %s is not empty and does not contain previous render (%s)", outDir, renderMetadataFileName)
+	}
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(outDir, entry.Name())); err != nil {
+			return fmt.Errorf("failed to clear previous render in %s: %v", outDir, err)
+		}
+	}
+	return nil
+}
+
+// printRenderedDir prints the list of files in the synthesized directory and content of generated tfvars with sensitive values masked
+func printRenderedDir(renderedDir string, sensitiveValues []string) error {
+	var tfvarsFiles []string
+
+	fmt.Println("# files")
+	err := filepath.WalkDir(renderedDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".terraform" {
+			return filepath.SkipDir
+		}
+		relPath, _ := filepath.Rel(renderedDir, path)
+		if relPath == "." || utils.IsTempDirInternalFile(relPath) {
+			return nil
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			linkTarget, _ := os.Readlink(path)
+			fmt.Println(relPath + " -> " + linkTarget)
+			return nil
+		}
+		if d.IsDir() {
+			fmt.Println(relPath + "/")
+			return nil
+		}
+		fmt.Println(relPath)
+		if strings.HasSuffix(relPath, ".auto.tfvars.json") {
+			tfvarsFiles = append(tfvarsFiles, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, tfvarsFile := range tfvarsFiles {
+		tfvarsBytes, err := os.ReadFile(tfvarsFile)
+		if err != nil {
+			return err
+		}
+		var tfvars map[string]interface{}
+		if err := json.Unmarshal(tfvarsBytes, &tfvars); err != nil {
+			return fmt.Errorf("failed to parse %s: %v", tfvarsFile, err)
+		}
+		tfvarsBytes, err = json.MarshalIndent(tfvars, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println("\n# " + filepath.Base(tfvarsFile))
+		fmt.Println(utils.MaskSecrets(string(tfvarsBytes), sensitiveValues))
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(renderCmd)
+
+	renderCmd.Flags().StringArrayP("dimension", "d", []string{}, "specify dimensions from invetory like dim:name")
+	renderCmd.Flags().StringP("unit", "u", "", "specify unit")
+	renderCmd.Flags().StringP("org", "o", "", "specify org")
+	renderCmd.Flags().StringP("workspace", "w", "master", "specify workspace for IaCConsole DB")
+	renderCmd.Flags().Bool("fail-on-lock", false, "fail instead of waiting if temp dir is locked by another run")
+	renderCmd.Flags().String("out", "", "directory to write the synthesized unit to")
+	renderCmd.Flags().Bool("print", false, "print list of files and generated tfvars instead of writing to --out")
+	if err := renderCmd.MarkFlagRequired("unit"); err != nil {
+		log.Fatalf("Error marking flag 'unit' as required: %v", err)
+	}
+	if err := renderCmd.MarkFlagRequired("org"); err != nil {
+		log.Fatalf("Error marking flag 'org' as required: %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPrepareRenderOutDir(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     []string
+		wantErr   string
+		wantFiles int
+	}{
+		{name: "missing dir"},
+		{name: "empty dir", files: []string{}},
+		{name: "previous render is cleared", files: []string{renderMetadataFileName, "main.tf", "modules/vpc/main.tf"}},
+		{name: "not a render", files: []string{"main.tf"}, wantErr: "is not empty", wantFiles: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outDir := filepath.Join(t.TempDir(), "out")
+			if tt.files != nil {
+				if err := os.MkdirAll(outDir, 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, name := range tt.files {
+				if err := os.MkdirAll(filepath.Dir(filepath.Join(outDir, name)), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(outDir, name), []byte("{}"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := prepareRenderOutDir(outDir)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			entries, _ := os.ReadDir(outDir)
+			if len(entries) != tt.wantFiles {
+				t.Errorf("%d entries left in --out, want %d", len(entries), tt.wantFiles)
+			}
+		})
+	}
+}
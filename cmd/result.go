@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/alt-dima/iacconsole-cli/utils"
+)
+
+// execResult is the document written by exec --result-json
+type execResult struct {
+	Org             string                 `json:"org"`
+	Unit            string                 `json:"unit"`
+	Workspace       string                 `json:"workspace"`
+	Dimensions      map[string]string      `json:"dimensions"`
+	StatePath       string                 `json:"state_path"`
+	BackendConfig   map[string]interface{} `json:"backend_config"`
+	TempDir         string                 `json:"temp_dir"`
+	CommandLine     []string               `json:"command_line"`
+	StartedAt       time.Time              `json:"started_at"`
+	FinishedAt      time.Time              `json:"finished_at"`
+	DurationSeconds float64                `json:"duration_seconds"`
+	ExitCode        int                    `json:"exit_code"`
+	Error           string                 `json:"error,omitempty"`
+}
+
+// fillFromState copies target of the execution from the session state, secrets in backend config are masked
+func (r *execResult) fillFromState(s *utils.State, backendConfig map[string]interface{}) {
+	r.Org = s.OrgName
+	r.Unit = s.UnitName
+	r.Workspace = s.Workspace
+	r.StatePath = s.StateS3Path
+	r.TempDir = s.CmdWorkTempDir
+	if s.ParsedDimensions != nil {
+		r.Dimensions = s.ParsedDimensions
+	}
+	if backendConfig != nil {
+		r.BackendConfig = utils.MaskBackendConfig(backendConfig)
+	}
+}
+
+// write finishes the result with exitCode and err and writes it to resultJsonPath, if path is set
+func (r *execResult) write(resultJsonPath string, exitCode int, err error) {
+	if resultJsonPath == "" {
+		return
+	}
+
+	r.FinishedAt = time.Now().UTC()
+	r.DurationSeconds = r.FinishedAt.Sub(r.StartedAt).Seconds()
+	r.ExitCode = exitCode
+	if err != nil {
+		r.Error = err.Error()
+	}
+
+	if err := writeJsonFile(r, resultJsonPath); err != nil {
+		log.Printf("Failed to write result json: %v", err)
+	}
+}
+
+func writeJsonFile(document any, jsonPath string) error {
+	documentBytes, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal json: %v", err)
+	}
+	return os.WriteFile(jsonPath, documentBytes, 0644)
+}
+
+// dimArgsToMap converts dimension args key:value to the map, used before dimensions are parsed
+func dimArgsToMap(dimensionsArgs []string) map[string]string {
+	dimensions := make(map[string]string, len(dimensionsArgs))
+	for _, dimension := range dimensionsArgs {
+		dimKey, dimValue, _ := strings.Cut(dimension, ":")
+		dimensions[dimKey] = dimValue
+	}
+	return dimensions
+}
@@ -11,6 +11,7 @@ import (
 
 var cfgFile string
 var Verbose bool
+var Offline bool
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
@@ -43,6 +44,7 @@ func init() {
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.iacconsolerc)")
 	rootCmd.PersistentFlags().BoolVarP(&Verbose, "verbose", "v", false, "verbose output")
+	rootCmd.PersistentFlags().BoolVar(&Offline, "offline", false, "read IaCConsole API inventory only from the local cache")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
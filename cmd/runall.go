@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/alt-dima/iacconsole-cli/utils"
+	"github.com/spf13/cobra"
+)
+
+// runAllCmd represents the run-all command
+var runAllCmd = &cobra.Command{
+	Use:   "run-all",
+	Short: "Execute OpenTofu command for all units of the org in dependency order",
+	Args:  cobra.MinimumNArgs(1),
+	Long: `Discovers all units of the org, orders them by depends_on from unit_manifest.json and executes
+OpenTofu command after -- for every unit. destroy is executed in reverse order.
+Units depending on a failed unit are skipped.`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		initConfig()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		sigs := make(chan os.Signal, 2)
+		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
+		// the first signal stops starting next units, every signal is passed to the running unit
+		interrupted := make(chan struct{})
+		childSigs := make(chan os.Signal, 2)
+		go func() {
+			for sig := range sigs {
+				select {
+				case <-interrupted:
+				default:
+					close(interrupted)
+				}
+				select {
+				case childSigs <- sig:
+				default:
+				}
+			}
+		}()
+
+		s := &utils.State{}
+		s.OrgName, _ = cmd.Flags().GetString("org")
+		workspace, _ := cmd.Flags().GetString("workspace")
+		dimensionsFlags, _ := cmd.Flags().GetStringArray("dimension")
+		forceCleanTempDir, _ := cmd.Flags().GetBool("clean")
+		// every unit expands dimensions with its own multi dimensions, here they are only validated before any unit runs
+		if _, err := utils.ExpandDimArgs(dimensionsFlags, nil); err != nil {
+			log.Fatalf("Failed to parse dimensions: %v", err)
+		}
+
+		unitsOrgPath, _ := filepath.Abs(s.GetStringFromViperByOrgOrDefault("units_path") + "/" + s.OrgName)
+		units, err := utils.DiscoverUnits(unitsOrgPath, "unit_manifest.json")
+		if err != nil {
+			log.Fatalf("Failed to discover units: %v", err)
+		}
+		if len(units) == 0 {
+			log.Fatalf("no units found in %s", unitsOrgPath)
+		}
+
+		sortedUnits, err := utils.SortUnitsByDependencies(units)
+		if err != nil {
+			log.Fatalf("Failed to order units: %v", err)
+		}
+
+		// blockers are the units whose failure must stop the unit from running:
+		// dependencies for regular actions and dependents for destroy
+		blockers := make(map[string][]string, len(units))
+		isDestroy := args[0] == "destroy"
+		for unitName, unitManifest := range units {
+			for _, dependency := range unitManifest.Dependencies() {
+				if isDestroy {
+					blockers[dependency] = append(blockers[dependency], unitName)
+				} else {
+					blockers[unitName] = append(blockers[unitName], dependency)
+				}
+			}
+		}
+		if isDestroy {
+			for i, j := 0, len(sortedUnits)-1; i < j; i, j = i+1, j-1 {
+				sortedUnits[i], sortedUnits[j] = sortedUnits[j], sortedUnits[i]
+			}
+		}
+		log.Printf("run-all order: %v", sortedUnits)
+
+		var execFlags []string
+		execFlags = append(execFlags, "--org", s.OrgName, "--workspace", workspace)
+		for _, dimension := range dimensionsFlags {
+			execFlags = append(execFlags, "--dimension", dimension)
+		}
+		if forceCleanTempDir {
+			execFlags = append(execFlags, "--clean")
+		}
+
+		failedUnits := make(map[string]bool)
+		results := make([]runResult, 0, len(sortedUnits))
+		for _, unitName := range sortedUnits {
+			result := runResult{Name: unitName, Status: "skipped", ExitCode: -1}
+
+			isInterrupted := false
+			select {
+			case <-interrupted:
+				isInterrupted = true
+			default:
+			}
+
+			var failedBlocker string
+			for _, blocker := range blockers[unitName] {
+				if failedUnits[blocker] {
+					failedBlocker = blocker
+					break
+				}
+			}
+
+			switch {
+			case isInterrupted:
+				log.Printf("run-all interrupted, skipping unit %s", unitName)
+			case failedBlocker != "":
+				log.Printf("skipping unit %s because unit %s failed", unitName, failedBlocker)
+			default:
+				log.Printf("run-all executing unit %s", unitName)
+				childCommand, err := newSelfExecCommand(append([]string{"--unit", unitName}, execFlags...), args)
+				if err != nil {
+					log.Fatalf("Failed to prepare child command: %v", err)
+				}
+				childCommand.Stdin = os.Stdin
+				childCommand.Stdout = os.Stdout
+				childCommand.Stderr = os.Stderr
+
+				startTime := time.Now()
+				result.ExitCode = runChildCommand(childCommand, childSigs)
+				result.Duration = time.Since(startTime).Round(time.Second)
+				result.Status = "success"
+				if result.ExitCode != 0 {
+					result.Status = "failed"
+				}
+			}
+
+			if result.Status != "success" {
+				failedUnits[unitName] = true
+			}
+			results = append(results, result)
+		}
+
+		printRunResults("run-all summary", "UNIT", results)
+
+		if len(failedUnits) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(runAllCmd)
+
+	runAllCmd.Flags().StringArrayP("dimension", "d", []string{}, "specify dimensions from invetory like dim:name")
+	runAllCmd.Flags().StringP("org", "o", "", "specify org")
+	runAllCmd.Flags().StringP("workspace", "w", "master", "specify workspace for IaCConsole DB")
+	runAllCmd.Flags().BoolP("clean", "c", false, "remove tmp after execution")
+	if err := runAllCmd.MarkFlagRequired("org"); err != nil {
+		log.Fatalf("Error marking flag 'org' as required: %v", err)
+	}
+}
@@ -24,11 +24,6 @@ func ExecuteAgentCommand(conn *websocket.Conn, cmd AgentCommand, state *State) {
 		state.Workspace = "master"
 	}
 
-	state.ParsedDimensions = make(map[string]string)
-	for _, dp := range cmd.Dimensions {
-		state.ParsedDimensions[dp.Key] = dp.Value
-	}
-
 	// Convert dimensions to DimensionsFlags format for compatibility
 	state.DimensionsFlags = make([]string, 0, len(cmd.Dimensions))
 	for _, dp := range cmd.Dimensions {
@@ -65,7 +60,16 @@ func ExecuteAgentCommand(conn *websocket.Conn, cmd AgentCommand, state *State) {
 	}
 
 	// 3. Parse unit manifest (needed before SetupBackendConfig)
-	state.ParseUnitManifest("unit_manifest.json")
+	if err := state.ParseUnitManifest("unit_manifest.json"); err != nil {
+		log.Printf("Error parsing unit manifest: %v", err)
+		sendComplete(conn, cmd.ID, 1, err.Error())
+		return
+	}
+	if err := state.ParseDimensions(); err != nil {
+		log.Printf("Error parsing dimensions: %v", err)
+		sendComplete(conn, cmd.ID, 1, err.Error())
+		return
+	}
 
 	// 4. Setup backend config (depends on UnitManifest)
 	backendConfig := state.SetupBackendConfig()
@@ -76,6 +80,7 @@ func ExecuteAgentCommand(conn *websocket.Conn, cmd AgentCommand, state *State) {
 		sendComplete(conn, cmd.ID, 1, err.Error())
 		return
 	}
+	defer state.UnlockTemp()
 
 	// 6. Generate variables - handle errors gracefully
 	if err := state.GenerateVarsByDims(); err != nil {
@@ -102,6 +107,12 @@ func ExecuteAgentCommand(conn *websocket.Conn, cmd AgentCommand, state *State) {
 		return
 	}
 
+	if err := state.GenerateVarsByInputsFrom(); err != nil {
+		log.Printf("Error generating vars from inputs_from units: %v", err)
+		sendComplete(conn, cmd.ID, 1, err.Error())
+		return
+	}
+
 	// 7. Prepare execution
 	cmdToExec := state.GetStringFromViperByOrgOrDefault("cmd_to_exec")
 	if cmdToExec == "" {
@@ -116,6 +127,16 @@ func ExecuteAgentCommand(conn *websocket.Conn, cmd AgentCommand, state *State) {
 	}
 	args = append(args, cmd.ExtraArgs...)
 
+	hookStdout := NewMaskingWriter(os.Stdout, state.SensitiveValues)
+	hookStderr := NewMaskingWriter(os.Stderr, state.SensitiveValues)
+	defer hookStdout.Flush()
+	defer hookStderr.Flush()
+	if err := state.RunHooks("before", cmd.Action, 0, hookStdout, hookStderr); err != nil {
+		log.Printf("Error running hooks: %v", err)
+		sendComplete(conn, cmd.ID, 1, err.Error())
+		return
+	}
+
 	// 8. Spawn process
 	log.Printf("Agent executing: %s %s", cmdToExec, strings.Join(args, " "))
 	child := exec.Command(cmdToExec, args...)
@@ -134,8 +155,8 @@ func ExecuteAgentCommand(conn *websocket.Conn, cmd AgentCommand, state *State) {
 	// 9. Stream output
 	var mu sync.Mutex
 	done := make(chan bool)
-	go streamPipe(conn, &mu, cmd.ID, "stdout", stdout, done)
-	go streamPipe(conn, &mu, cmd.ID, "stderr", stderr, done)
+	go streamPipe(conn, &mu, cmd.ID, "stdout", stdout, state.SensitiveValues, done)
+	go streamPipe(conn, &mu, cmd.ID, "stderr", stderr, state.SensitiveValues, done)
 
 	err = child.Wait()
 	<-done
@@ -150,6 +171,10 @@ func ExecuteAgentCommand(conn *websocket.Conn, cmd AgentCommand, state *State) {
 		}
 	}
 
+	if err := state.RunHooks("after", cmd.Action, exitCode, hookStdout, hookStderr); err != nil {
+		log.Printf("Error running hooks: %v", err)
+	}
+
 	// 10. Cleanup
 	if exitCode == 0 && (cmd.Action == "apply" || cmd.Action == "destroy") {
 		os.RemoveAll(state.CmdWorkTempDir)
@@ -160,13 +185,13 @@ func ExecuteAgentCommand(conn *websocket.Conn, cmd AgentCommand, state *State) {
 	mu.Unlock()
 }
 
-func streamPipe(conn *websocket.Conn, mu *sync.Mutex, cmdID string, stream string, pipe io.ReadCloser, done chan bool) {
+func streamPipe(conn *websocket.Conn, mu *sync.Mutex, cmdID string, stream string, pipe io.ReadCloser, sensitiveValues []string, done chan bool) {
 	defer pipe.Close()
 	defer func() { done <- true }()
 
 	scanner := bufio.NewScanner(pipe)
 	for scanner.Scan() {
-		line := scanner.Text()
+		line := MaskSecrets(scanner.Text(), sensitiveValues)
 		msg := AgentOutput{
 			AgentMessage: AgentMessage{Type: "output"},
 			CommandID:    cmdID,
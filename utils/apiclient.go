@@ -0,0 +1,226 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	defaultApiTimeout = 30 * time.Second
+	defaultApiRetries = 3
+	apiRetryBackoff   = 500 * time.Millisecond
+)
+
+// apiClient calls IaCConsole API with timeouts, retries on network errors and 5xx responses and Authorization header
+// instead of credentials in the URL. GET responses are cached on disk and revalidated with ETag
+type apiClient struct {
+	endpoint *ApiEndpoint
+	timeout  time.Duration
+	retries  int
+	// backoff before the first retry, doubled for every next one
+	backoff time.Duration
+	// cacheDir is empty if cache is disabled
+	cacheDir string
+	offline  bool
+}
+
+// apiResponse is the status and body of API response, also stored in the cache
+type apiResponse struct {
+	Url        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+	ETag       string `json:"etag,omitempty"`
+	Body       []byte `json:"body"`
+}
+
+// errApiOffline is returned in offline mode if the response is not cached
+var errApiOffline = errors.New("not found in IaCConsole API cache (--offline)")
+
+// newApiClient creates client for the API endpoint of the State.
+// Timeout, retries and cache dir are configured with api_timeout, api_retries and api_cache_dir for the org or in defaults
+func (s *State) newApiClient() (*apiClient, error) {
+	var err error
+	client := &apiClient{endpoint: s.ApiEndpoint, timeout: defaultApiTimeout, retries: defaultApiRetries, backoff: apiRetryBackoff, offline: s.Offline}
+	if apiTimeout := s.GetStringFromViperByOrgOrDefault("api_timeout"); apiTimeout != "" {
+		if client.timeout, err = time.ParseDuration(apiTimeout); err != nil {
+			return nil, fmt.Errorf("invalid api_timeout %q: %v", apiTimeout, err)
+		}
+	}
+	if apiRetries := s.GetStringFromViperByOrgOrDefault("api_retries"); apiRetries != "" {
+		if _, err := fmt.Sscan(apiRetries, &client.retries); err != nil {
+			return nil, fmt.Errorf("invalid api_retries %q: %v", apiRetries, err)
+		}
+	}
+
+	client.cacheDir = s.GetStringFromViperByOrgOrDefault("api_cache_dir")
+	if client.cacheDir == "" {
+		if userCacheDir, err := os.UserCacheDir(); err == nil {
+			client.cacheDir = filepath.Join(userCacheDir, "iacconsole", "api")
+		}
+	}
+	if client.cacheDir == "" && client.offline {
+		return nil, fmt.Errorf("--offline requires api_cache_dir, no user cache dir found")
+	}
+	return client, nil
+}
+
+// get returns response from the API, revalidating cached one with ETag. In offline mode only cached response is returned
+func (c *apiClient) get(path string, query url.Values) (apiResponse, error) {
+	requestUrl := c.endpoint.BaseUrl + path + "?" + query.Encode()
+	cached, cacheErr := c.readCache(requestUrl)
+
+	if c.offline {
+		if cacheErr != nil {
+			// optional dimensions (like dim_defaults) not in the cache are skipped as not found
+			return apiResponse{}, fmt.Errorf("%s: %w: %w", requestUrl, ErrDimensionNotFound, errApiOffline)
+		}
+		return cached, nil
+	}
+
+	header := http.Header{}
+	if cacheErr == nil && cached.ETag != "" {
+		header.Set("If-None-Match", cached.ETag)
+	}
+	response, err := c.do(http.MethodGet, requestUrl, header, nil)
+	if err != nil {
+		return apiResponse{}, err
+	}
+	if response.StatusCode == http.StatusNotModified && cacheErr == nil {
+		return cached, nil
+	}
+	// not found responses are not cached, so a value created later is never hidden by the cache
+	if response.StatusCode == http.StatusOK {
+		if err := c.writeCache(response); err != nil {
+			log.Printf("failed to cache IaCConsole API response: %v", err)
+		}
+	}
+	return response, nil
+}
+
+// send executes request with JSON body, which is not cached
+func (c *apiClient) send(method string, path string, query url.Values, body []byte) (apiResponse, error) {
+	if c.offline {
+		return apiResponse{}, fmt.Errorf("%s %s is not possible with --offline", method, path)
+	}
+	header := http.Header{}
+	if body != nil {
+		header.Set("Content-Type", "application/json")
+	}
+	return c.do(method, c.endpoint.BaseUrl+path+"?"+query.Encode(), header, body)
+}
+
+// do executes request with timeout, retrying network errors and 5xx responses with exponential backoff
+func (c *apiClient) do(method string, requestUrl string, header http.Header, body []byte) (apiResponse, error) {
+	var lastErr error
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		if attempt > 0 {
+			backoff := c.backoff << (attempt - 1)
+			log.Printf("retrying %s %s in %s after: %v", method, requestUrl, backoff, lastErr)
+			time.Sleep(backoff)
+		}
+
+		response, err := c.doOnce(method, requestUrl, header, body)
+		var certificateErr *tls.CertificateVerificationError
+		if errors.As(err, &certificateErr) {
+			// retry will not fix TLS settings
+			return apiResponse{}, fmt.Errorf("%s %s failed: %v", method, requestUrl, err)
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if response.StatusCode >= 500 {
+			lastErr = fmt.Errorf("response %d %s", response.StatusCode, bytes.TrimSpace(response.Body))
+			continue
+		}
+		return response, nil
+	}
+	return apiResponse{}, fmt.Errorf("%s %s failed after %d attempts: %v", method, requestUrl, c.retries+1, lastErr)
+}
+
+func (c *apiClient) doOnce(method string, requestUrl string, header http.Header, body []byte) (apiResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, requestUrl, bytes.NewReader(body))
+	if err != nil {
+		return apiResponse{}, err
+	}
+	req.Header = header.Clone()
+	if authorization := c.endpoint.Authorization(); authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := c.endpoint.httpClient.Do(req)
+	if err != nil {
+		return apiResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return apiResponse{}, fmt.Errorf("reading body response failed: %v", err)
+	}
+	return apiResponse{Url: requestUrl, StatusCode: resp.StatusCode, ETag: resp.Header.Get("ETag"), Body: responseBody}, nil
+}
+
+// cachePath returns cache file of the URL (which contains org, dimension, value and workspace) for the account
+func (c *apiClient) cachePath(requestUrl string) string {
+	return filepath.Join(c.cacheDir, GetMD5Hash(c.endpoint.AccountID+" "+requestUrl)+".json")
+}
+
+func (c *apiClient) readCache(requestUrl string) (apiResponse, error) {
+	var cached apiResponse
+	if c.cacheDir == "" {
+		return cached, errors.New("cache is disabled")
+	}
+	cachedBytes, err := os.ReadFile(c.cachePath(requestUrl))
+	if err != nil {
+		return cached, err
+	}
+	if err := json.Unmarshal(cachedBytes, &cached); err != nil {
+		return cached, err
+	}
+	if cached.Url != requestUrl {
+		return cached, errors.New("cache key collision")
+	}
+	return cached, nil
+}
+
+// writeCache stores response readable only by the user, as inventory could contain sensitive values
+func (c *apiClient) writeCache(response apiResponse) error {
+	if c.cacheDir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(c.cacheDir, 0700); err != nil {
+		return err
+	}
+	responseBytes, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	tempFile, err := os.CreateTemp(c.cacheDir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+	if _, err := tempFile.Write(responseBytes); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+	// rename is atomic, parallel runs never read partially written cache
+	return os.Rename(tempFile.Name(), c.cachePath(response.Url))
+}
@@ -0,0 +1,190 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestApiClient(t *testing.T, serverUrl string, offline bool) *apiClient {
+	t.Helper()
+	endpoint, err := ParseApiEndpoint(strings.Replace(serverUrl, "http://", "http://acc:secret@", 1), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &apiClient{endpoint: endpoint, timeout: 5 * time.Second, retries: 2, backoff: 20 * time.Millisecond, cacheDir: t.TempDir(), offline: offline}
+}
+
+func TestApiClientRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		failures   int32
+		fail       func(w http.ResponseWriter)
+		wantErr    bool
+		wantCalls  int32
+		minElapsed time.Duration
+	}{
+		{
+			name:     "5xx then success",
+			failures: 2,
+			fail: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusBadGateway)
+			},
+			wantCalls:  3,
+			minElapsed: 20*time.Millisecond + 40*time.Millisecond,
+		},
+		{
+			name:     "network error then success",
+			failures: 1,
+			fail: func(w http.ResponseWriter) {
+				conn, _, err := w.(http.Hijacker).Hijack()
+				if err == nil {
+					conn.Close()
+				}
+			},
+			wantCalls:  2,
+			minElapsed: 20 * time.Millisecond,
+		},
+		{
+			name:     "5xx on every attempt",
+			failures: 10,
+			fail: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			wantErr:   true,
+			wantCalls: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) <= tt.failures {
+					tt.fail(w)
+					return
+				}
+				w.Write([]byte(`{"ok":true}`))
+			}))
+			defer server.Close()
+
+			client := newTestApiClient(t, server.URL, false)
+			started := time.Now()
+			response, err := client.get("/v1/dimension/org/env/dev", url.Values{})
+			elapsed := time.Since(started)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got response %d", response.StatusCode)
+				}
+			} else if err != nil || response.StatusCode != http.StatusOK {
+				t.Fatalf("unexpected response %d: %v", response.StatusCode, err)
+			}
+			if calls.Load() != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls.Load(), tt.wantCalls)
+			}
+			if elapsed < tt.minElapsed {
+				t.Errorf("retries took %s, want at least %s of backoff", elapsed, tt.minElapsed)
+			}
+		})
+	}
+}
+
+func TestApiClientAuthorization(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "acc" || password != "secret" {
+			t.Errorf("unexpected Authorization header %q", r.Header.Get("Authorization"))
+		}
+		if strings.Contains(r.RequestURI, "secret") || r.URL.User != nil {
+			t.Errorf("credentials are sent in the URL %s", r.RequestURI)
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client := newTestApiClient(t, server.URL, false)
+	if strings.Contains(client.endpoint.BaseUrl, "secret") {
+		t.Errorf("BaseUrl %s contains credentials", client.endpoint.BaseUrl)
+	}
+	if _, err := client.get("/v1/dimension/org/env/dev", url.Values{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.send(http.MethodPut, "/v1/dimension/org/env/dev", url.Values{}, []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestApiClientCache(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		switch r.URL.Path {
+		case "/v1/dimension/org/env/dev":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			w.Write([]byte(`{"cached":true}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := newTestApiClient(t, server.URL, false)
+	for i := 0; i < 2; i++ {
+		response, err := client.get("/v1/dimension/org/env/dev", url.Values{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if response.StatusCode != http.StatusOK || string(response.Body) != `{"cached":true}` {
+			t.Fatalf("request %d: unexpected response %d %s", i, response.StatusCode, response.Body)
+		}
+	}
+	if calls.Load() != 2 {
+		t.Errorf("calls = %d, want 2 (the second one revalidated with ETag)", calls.Load())
+	}
+	if response, err := client.get("/v1/dimension/org/env/missing", url.Values{}); err != nil || response.StatusCode != http.StatusNotFound {
+		t.Fatalf("unexpected response %d: %v", response.StatusCode, err)
+	}
+
+	offlineClient := &apiClient{endpoint: client.endpoint, cacheDir: client.cacheDir, offline: true}
+	tests := []struct {
+		name     string
+		client   *apiClient
+		path     string
+		wantBody string
+	}{
+		{name: "offline hit", client: offlineClient, path: "/v1/dimension/org/env/dev", wantBody: `{"cached":true}`},
+		{name: "offline miss", client: offlineClient, path: "/v1/dimension/org/env/prod"},
+		{name: "404 is not cached", client: offlineClient, path: "/v1/dimension/org/env/missing"},
+		{
+			name:   "cache is per account",
+			client: &apiClient{endpoint: &ApiEndpoint{BaseUrl: client.endpoint.BaseUrl, AccountID: "other"}, cacheDir: client.cacheDir, offline: true},
+			path:   "/v1/dimension/org/env/dev",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			callsBefore := calls.Load()
+			response, err := tt.client.get(tt.path, url.Values{})
+			if calls.Load() != callsBefore {
+				t.Errorf("offline client called the API")
+			}
+			if tt.wantBody == "" {
+				if !errors.Is(err, errApiOffline) || !isDimensionNotFound(err) {
+					t.Fatalf("expected offline cache miss, got %v", err)
+				}
+				return
+			}
+			if err != nil || string(response.Body) != tt.wantBody {
+				t.Fatalf("unexpected response %s: %v", response.Body, err)
+			}
+		})
+	}
+}
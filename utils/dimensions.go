@@ -1,33 +1,185 @@
 package utils
 
 import (
+	"fmt"
 	"log"
+	"os"
+	"slices"
+	"sort"
 	"strings"
+
+	"go.yaml.in/yaml/v3"
 )
 
-func (s *State) ParseDimensions() {
-	parsedDimArgs := parseDimArgs(s.DimensionsFlags)
+// ParseDimensions fills ParsedDimensions from -d args and defaults of the unit manifest. Values of multi dimensions are sorted
+// and kept in MultiDimensions, in ParsedDimensions they are joined with comma. All the problems are reported at once
+func (s *State) ParseDimensions() error {
+	parsedDimArgs, err := parseDimArgs(s.DimensionsFlags)
+	if err != nil {
+		return err
+	}
+
+	var dimensionErrors []string
+	for _, dimension := range s.UnitManifest.Dimensions {
+		dimValues, ok := parsedDimArgs[dimension.Name]
+		if !ok && len(dimension.Default) > 0 {
+			parsedDimArgs[dimension.Name] = dimension.Default
+			log.Printf("dimension %s not passed with -d arg, using default %s", dimension.Name, strings.Join(dimension.Default, ","))
+			continue
+		}
+		if !ok {
+			if dimension.IsRequired() {
+				dimensionErrors = append(dimensionErrors, "dimension "+dimension.Name+describeDimension(dimension)+" not passed with -d arg")
+			}
+			continue
+		}
+		for _, dimValue := range dimValues {
+			if err := dimension.checkAllowed(dimValue); err != nil {
+				dimensionErrors = append(dimensionErrors, err.Error())
+			}
+		}
+	}
+
+	s.ParsedDimensions = make(map[string]string, len(parsedDimArgs))
+	s.MultiDimensions = make(map[string][]string)
+	for dimKey, dimValues := range parsedDimArgs {
+		if s.UnitManifest.IsMultiDimension(dimKey) {
+			// values are joined with + in the state path and with comma in ParsedDimensions, so they must not contain them
+			for _, dimValue := range dimValues {
+				if strings.ContainsAny(dimValue, "+,") {
+					dimensionErrors = append(dimensionErrors, fmt.Sprintf("dimension %s: value %s of multi dimension must not contain + or ,", dimKey, dimValue))
+				}
+			}
+			sort.Strings(dimValues)
+			s.MultiDimensions[dimKey] = dimValues
+			s.ParsedDimensions[dimKey] = strings.Join(dimValues, ",")
+			continue
+		}
+		if len(dimValues) > 1 {
+			dimensionErrors = append(dimensionErrors, fmt.Sprintf("dimension %s accepts only one value, got %s. Declare it in multi_dimensions of unit manifest to pass several values", dimKey, strings.Join(dimValues, ", ")))
+		}
+		s.ParsedDimensions[dimKey] = dimValues[0]
+	}
+
+	if len(dimensionErrors) > 0 {
+		sort.Strings(dimensionErrors)
+		return fmt.Errorf("invalid dimensions:\n%s", strings.Join(dimensionErrors, "\n"))
+	}
+	return nil
+}
 
+// OptionalDimensions returns optional dimensions of the unit manifest not passed with -d arg
+func (s *State) OptionalDimensions() []string {
+	var dimKeys []string
 	for _, dimension := range s.UnitManifest.Dimensions {
-		if _, ok := parsedDimArgs[dimension]; !ok {
-			log.Fatalln("dimension " + dimension + " not passed with -d arg")
+		if _, ok := s.ParsedDimensions[dimension.Name]; !ok {
+			dimKeys = append(dimKeys, dimension.Name)
 		}
 	}
+	return dimKeys
+}
 
-	s.ParsedDimensions = parsedDimArgs
+func describeDimension(dimension unitDimension) string {
+	if dimension.Description == "" {
+		return ""
+	}
+	return " (" + dimension.Description + ")"
 }
 
-func parseDimArgs(dimensionsArgs []string) map[string]string {
-	parsedDimArgs := make(map[string]string)
+// parseDimArgs returns values of every dimension in the order of args, without duplicates
+func parseDimArgs(dimensionsArgs []string) (map[string][]string, error) {
+	parsedDimArgs := make(map[string][]string)
 	for _, dimension := range dimensionsArgs {
 		dimensionSlice := strings.SplitN(dimension, ":", 2)
 		if len(dimensionSlice) != 2 {
-			log.Fatalln("Invalid dimension format: " + dimension + ". Expected format: key:value")
+			return nil, fmt.Errorf("invalid dimension format: %s. Expected format: key:value", dimension)
 		}
 		if strings.HasPrefix(dimensionSlice[1], "dim_") {
-			log.Fatalln("dimension " + dimension + " with dim_ prefix can't be passed with -d arg")
+			return nil, fmt.Errorf("dimension %s with dim_ prefix can't be passed with -d arg", dimension)
+		}
+		if !slices.Contains(parsedDimArgs[dimensionSlice[0]], dimensionSlice[1]) {
+			parsedDimArgs[dimensionSlice[0]] = append(parsedDimArgs[dimensionSlice[0]], dimensionSlice[1])
+		}
+	}
+	return parsedDimArgs, nil
+}
+
+// ExpandDimArgs expands dimension args with several values per key (-d account:a,b -d datacenter:x -d datacenter:y)
+// into the cartesian product of single value dimension args, one slice per combination.
+// Everything after the first colon are comma separated values of the key, so -d key:a,other:b has values a and other:b.
+// All the values of multiDimKeys are kept together in every combination
+func ExpandDimArgs(dimensionsArgs []string, multiDimKeys []string) ([][]string, error) {
+	var dimKeys []string
+	dimValues := make(map[string][]string)
+
+	for _, dimension := range dimensionsArgs {
+		dimKey, dimValuesList, found := strings.Cut(dimension, ":")
+		if !found || dimKey == "" {
+			return nil, fmt.Errorf("invalid dimension format: %s. Expected format: key:value", dimension)
+		}
+		for _, dimValue := range strings.Split(dimValuesList, ",") {
+			if dimValue == "" {
+				return nil, fmt.Errorf("invalid dimension format: %s. Expected format: key:value", dimension)
+			}
+			// checked before combinations are executed, like parseDimArgs does for every one of them
+			if strings.HasPrefix(dimValue, "dim_") {
+				return nil, fmt.Errorf("dimension %s:%s with dim_ prefix can't be passed with -d arg", dimKey, dimValue)
+			}
+			if _, ok := dimValues[dimKey]; !ok {
+				dimKeys = append(dimKeys, dimKey)
+			}
+			if !slices.Contains(dimValues[dimKey], dimValue) {
+				dimValues[dimKey] = append(dimValues[dimKey], dimValue)
+			}
+		}
+	}
+
+	combinations := [][]string{{}}
+	for _, dimKey := range dimKeys {
+		if slices.Contains(multiDimKeys, dimKey) {
+			for i := range combinations {
+				for _, dimValue := range dimValues[dimKey] {
+					combinations[i] = append(combinations[i], dimKey+":"+dimValue)
+				}
+			}
+			continue
+		}
+		var expanded [][]string
+		for _, combination := range combinations {
+			for _, dimValue := range dimValues[dimKey] {
+				expanded = append(expanded, append(slices.Clone(combination), dimKey+":"+dimValue))
+			}
+		}
+		combinations = expanded
+	}
+
+	return combinations, nil
+}
+
+// ReadDimMatrixFile reads YAML or JSON file with dimension keys and lists of values, like {"account": ["a", "b"]},
+// and returns it as dimension args key:value
+func ReadDimMatrixFile(matrixFilePath string) ([]string, error) {
+	matrixBytes, err := os.ReadFile(matrixFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read matrix file: %v", err)
+	}
+
+	var matrix map[string][]string
+	if err := yaml.Unmarshal(matrixBytes, &matrix); err != nil {
+		return nil, fmt.Errorf("failed to parse matrix file %s: %v", matrixFilePath, err)
+	}
+
+	dimKeys := make([]string, 0, len(matrix))
+	for dimKey := range matrix {
+		dimKeys = append(dimKeys, dimKey)
+	}
+	sort.Strings(dimKeys)
+
+	var dimensionsArgs []string
+	for _, dimKey := range dimKeys {
+		for _, dimValue := range matrix[dimKey] {
+			dimensionsArgs = append(dimensionsArgs, dimKey+":"+dimValue)
 		}
-		parsedDimArgs[dimensionSlice[0]] = dimensionSlice[1]
 	}
-	return parsedDimArgs
+	return dimensionsArgs, nil
 }
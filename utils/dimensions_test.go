@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDimensions(t *testing.T) {
+	manifest := unitManifestStruct{
+		Dimensions:      []unitDimension{{Name: "account"}, {Name: "region", Multi: true}, {Name: "size", Default: stringList{"small"}}},
+		MultiDimensions: []string{"region"},
+	}
+	tests := []struct {
+		name          string
+		args          []string
+		want          map[string]string
+		wantMulti     map[string][]string
+		wantStatePath string
+		wantErr       string
+	}{
+		{
+			name:          "multi values are sorted and default is applied",
+			args:          []string{"account:dev", "region:us", "region:eu"},
+			want:          map[string]string{"account": "dev", "region": "eu,us", "size": "small"},
+			wantMulti:     map[string][]string{"region": {"eu", "us"}},
+			wantStatePath: "org_org/account_dev/region_eu+us/size_small/vpc.tfstate",
+		},
+		{
+			name:    "several values of not multi dimension",
+			args:    []string{"account:dev", "account:prod", "region:eu"},
+			wantErr: "dimension account accepts only one value",
+		},
+		{
+			name:    "multi value with +",
+			args:    []string{"account:dev", "region:eu+us"},
+			wantErr: "dimension region: value eu+us of multi dimension must not contain + or ,",
+		},
+		{
+			name:    "multi value with comma",
+			args:    []string{"account:dev", "region:eu,us"},
+			wantErr: "dimension region: value eu,us of multi dimension must not contain + or ,",
+		},
+		{
+			name:    "missing required dimension",
+			args:    []string{"region:eu"},
+			wantErr: "dimension account not passed with -d arg",
+		},
+		{
+			name:    "dim_ value",
+			args:    []string{"account:dim_defaults", "region:eu"},
+			wantErr: "with dim_ prefix can't be passed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &State{OrgName: "org", UnitName: "vpc", UnitManifest: manifest, DimensionsFlags: tt.args}
+			err := s.ParseDimensions()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(s.ParsedDimensions, tt.want) {
+				t.Errorf("ParsedDimensions = %v, want %v", s.ParsedDimensions, tt.want)
+			}
+			if !reflect.DeepEqual(s.MultiDimensions, tt.wantMulti) {
+				t.Errorf("MultiDimensions = %v, want %v", s.MultiDimensions, tt.wantMulti)
+			}
+			s.SetupBackendConfig()
+			if s.StateS3Path != tt.wantStatePath {
+				t.Errorf("StateS3Path = %s, want %s", s.StateS3Path, tt.wantStatePath)
+			}
+		})
+	}
+}
+
+func TestExpandDimArgs(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		multiDimKeys []string
+		want         [][]string
+		wantErr      string
+	}{
+		{
+			name: "single values",
+			args: []string{"account:dev", "datacenter:eu1"},
+			want: [][]string{{"account:dev", "datacenter:eu1"}},
+		},
+		{
+			name: "cartesian product of comma separated and repeated values",
+			args: []string{"account:dev,prod", "datacenter:eu1", "datacenter:us1"},
+			want: [][]string{
+				{"account:dev", "datacenter:eu1"},
+				{"account:dev", "datacenter:us1"},
+				{"account:prod", "datacenter:eu1"},
+				{"account:prod", "datacenter:us1"},
+			},
+		},
+		{
+			name: "value with colon after comma belongs to the same key",
+			args: []string{"key:a,other:b"},
+			want: [][]string{{"key:a"}, {"key:other:b"}},
+		},
+		{
+			name: "duplicates are removed",
+			args: []string{"account:dev,dev", "account:dev"},
+			want: [][]string{{"account:dev"}},
+		},
+		{
+			name:         "multi dimension values stay together",
+			args:         []string{"account:dev,prod", "peer:dc1,dc2"},
+			multiDimKeys: []string{"peer"},
+			want:         [][]string{{"account:dev", "peer:dc1", "peer:dc2"}, {"account:prod", "peer:dc1", "peer:dc2"}},
+		},
+		{
+			name: "no args",
+			want: [][]string{{}},
+		},
+		{
+			name:    "missing colon",
+			args:    []string{"account"},
+			wantErr: "invalid dimension format: account",
+		},
+		{
+			name:    "empty key",
+			args:    []string{":dev"},
+			wantErr: "invalid dimension format: :dev",
+		},
+		{
+			name:    "empty value after comma",
+			args:    []string{"account:dev,"},
+			wantErr: "invalid dimension format: account:dev,",
+		},
+		{
+			name:    "dim_ value is rejected before expansion",
+			args:    []string{"account:dev,dim_defaults"},
+			wantErr: "dimension account:dim_defaults with dim_ prefix can't be passed with -d arg",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandDimArgs(tt.args, tt.multiDimKeys)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("combinations = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
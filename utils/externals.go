@@ -3,12 +3,10 @@ package utils
 import (
 	"crypto/md5"
 	"encoding/hex"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
-	"os"
+	"regexp"
 	"strings"
 
 	"github.com/spf13/viper"
@@ -36,6 +34,14 @@ func (s *State) GetObjectFromViperByOrgOrDefault(keyName string) map[string]any
 	}
 }
 
+func (s *State) GetBoolFromViperByOrgOrDefault(keyName string) bool {
+	if viper.IsSet(s.OrgName + "." + keyName) {
+		return viper.GetBool(s.OrgName + "." + keyName)
+	} else {
+		return viper.GetBool("defaults." + keyName)
+	}
+}
+
 func (s *State) SetupBackendConfig() map[string]interface{} {
 	var stateS3Path string
 	if !viper.IsSet(s.OrgName + ".backend") {
@@ -43,7 +49,15 @@ func (s *State) SetupBackendConfig() map[string]interface{} {
 	}
 
 	for _, dimension := range s.UnitManifest.Dimensions {
-		stateS3Path = stateS3Path + dimension + "_" + s.ParsedDimensions[dimension] + "/"
+		dimValue, ok := s.ParsedDimensions[dimension.Name]
+		if !ok || !dimension.InStatePath() {
+			continue
+		}
+		if dimValues, ok := s.MultiDimensions[dimension.Name]; ok {
+			// values are sorted, so the path does not depend on the order of -d args
+			dimValue = strings.Join(dimValues, "+")
+		}
+		stateS3Path = stateS3Path + dimension.Name + "_" + dimValue + "/"
 	}
 	s.StateS3Path = stateS3Path + s.UnitName + ".tfstate"
 
@@ -60,65 +74,48 @@ func (s *State) SetupBackendConfig() map[string]interface{} {
 	return backendConfigMap
 }
 
+// GetDimData returns dimension data from the inventory provider of the org,
+// nil without error if dimension is not found and skipOnNotFound is set
 func (s *State) GetDimData(dimensionKey string, dimensionValue string, skipOnNotFound bool) (map[string]interface{}, error) {
-	var dimensionJsonMap map[string]interface{}
-
-	if s.IacconsoleApiUrl == "" {
-		inventroyJsonPath := s.InventoryPath + "/" + dimensionKey + "/" + dimensionValue + ".json"
-		dimensionJsonBytes, err := os.ReadFile(inventroyJsonPath)
-		if err != nil {
-			if os.IsNotExist(err) && skipOnNotFound {
-				log.Println("inventory files: Optional dimension " + s.OrgName + "/" + dimensionKey + "/" + dimensionValue + " not found, skipping")
-				return dimensionJsonMap, nil
-			}
-			return nil, err
-		}
-		err = json.Unmarshal(dimensionJsonBytes, &dimensionJsonMap)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		resp, err := http.Get(s.IacconsoleApiUrl + "/v1/dimension/" + s.OrgName + "/" + dimensionKey + "/" + dimensionValue + "?workspace=" + s.Workspace + "&fallbacktomaster=true")
-		if err != nil {
-			return nil, err
-		}
+	provider, err := s.InventoryProvider()
+	if err != nil {
+		return nil, err
+	}
 
-		if resp.StatusCode == 404 {
-			resp.Body.Close()
+	dimensionJsonMap, err := provider.GetDimension(dimensionKey, dimensionValue)
+	if err != nil {
+		if isDimensionNotFound(err) {
+			if skipOnNotFound && errors.Is(err, errApiOffline) {
+				// it could exist in the API, only the cache is checked
+				log.Println("warning: " + s.InventoryProviderName() + ": optional dimension " + s.OrgName + "/" + dimensionKey + "/" + dimensionValue + " is not in IaCConsole API cache, skipping with --offline")
+				return nil, nil
+			}
 			if skipOnNotFound {
-				log.Println("optional dimension " + s.OrgName + "/" + dimensionKey + "/" + dimensionValue + " not found, skipping")
-				return dimensionJsonMap, nil
+				log.Println(s.InventoryProviderName() + ": optional dimension " + s.OrgName + "/" + dimensionKey + "/" + dimensionValue + " not found, skipping")
+				return nil, nil
 			}
-			log.Println("requested dimension not found response 404:" + resp.Request.URL.String())
-			return nil, fmt.Errorf("dimension %s/%s/%s not found", s.OrgName, dimensionKey, dimensionValue)
+			return nil, fmt.Errorf("dimension %s/%s/%s not found in %s inventory: %w", s.OrgName, dimensionKey, dimensionValue, s.InventoryProviderName(), err)
 		}
+		return nil, err
+	}
 
-		if resp.StatusCode != 200 {
-			resp.Body.Close()
-			return nil, fmt.Errorf("request %s/%s/%s?workspace=%s failed with response: %v", s.OrgName, dimensionKey, dimensionValue, s.Workspace, resp.StatusCode)
-		}
-		defer resp.Body.Close()
+	return s.resolveExtends(provider, dimensionKey, dimensionValue, dimensionJsonMap)
+}
 
-		dimensionJsonBytes, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("reading body response failed: %s", err)
-		}
+func isDimensionNotFound(err error) bool {
+	return errors.Is(err, ErrDimensionNotFound)
+}
 
-		var IacConsoleDBResponse IaCConsoleDBResponse
-		err = json.Unmarshal(dimensionJsonBytes, &IacConsoleDBResponse)
-		if err != nil {
-			return nil, fmt.Errorf("error during unmarshal json response: %v", err)
-		}
+var secretBackendConfigKey = regexp.MustCompile(`(?i)(secret|password|passwd|token|access_key|credentials|private_key|client_key|sas)`)
 
-		if len(IacConsoleDBResponse.Dimensions) != 1 {
-			return nil, fmt.Errorf("should be only one dimension in response")
-		}
-		if IacConsoleDBResponse.Error != "" {
-			log.Println(IacConsoleDBResponse.Error)
+// MaskBackendConfig returns copy of backend config with values of secret keys (like secret_key or token) replaced by ***
+func MaskBackendConfig(backendConfig map[string]interface{}) map[string]interface{} {
+	maskedBackendConfig := make(map[string]interface{}, len(backendConfig))
+	for param, value := range backendConfig {
+		if secretBackendConfigKey.MatchString(param) {
+			value = "***"
 		}
-		dimensionJsonMap = IacConsoleDBResponse.Dimensions[0].DimData
-
+		maskedBackendConfig[param] = value
 	}
-
-	return dimensionJsonMap, nil
+	return maskedBackendConfig
 }
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"bytes"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGetDimDataNotFound(t *testing.T) {
+	tests := []struct {
+		name           string
+		offline        bool
+		warmCache      bool
+		value          string
+		skipOnNotFound bool
+		want           map[string]interface{}
+		wantErr        string
+		wantLog        string
+	}{
+		{
+			name:    "required value not in the API",
+			value:   "prod",
+			wantErr: "dimension offline-org/account/prod not found in api inventory",
+		},
+		{
+			name:    "required value not in the cache reports offline",
+			offline: true,
+			value:   "dev",
+			wantErr: "not found in IaCConsole API cache (--offline)",
+		},
+		{
+			name:      "cached value offline",
+			offline:   true,
+			warmCache: true,
+			value:     "dev",
+			want:      map[string]interface{}{"id": 1.0},
+		},
+		{
+			name:           "optional value not in the API",
+			value:          "dim_defaults",
+			skipOnNotFound: true,
+			wantLog:        "optional dimension offline-org/account/dim_defaults not found, skipping",
+		},
+		{
+			name:           "optional value not in the cache is skipped with warning",
+			offline:        true,
+			value:          "dim_defaults",
+			skipOnNotFound: true,
+			wantLog:        "warning: api: optional dimension offline-org/account/dim_defaults is not in IaCConsole API cache, skipping with --offline",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeInventoryApi{values: map[string]map[string]map[string]interface{}{"account": {"dev": {"id": 1.0}}}}
+			s := newFakeInventoryApiState(t, "offline-org", api)
+			if tt.warmCache {
+				if _, err := s.GetDimData("account", tt.value, false); err != nil {
+					t.Fatal(err)
+				}
+				s = &State{OrgName: s.OrgName, Workspace: s.Workspace, ApiEndpoint: s.ApiEndpoint}
+			}
+			s.Offline = tt.offline
+
+			var logs bytes.Buffer
+			defer log.SetOutput(log.Writer())
+			log.SetOutput(&logs)
+
+			got, err := s.GetDimData("account", tt.value, tt.skipOnNotFound)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("data = %v, want %v", got, tt.want)
+			}
+			if !strings.Contains(logs.String(), tt.wantLog) {
+				t.Errorf("expected log containing %q, got:\n%s", tt.wantLog, logs.String())
+			}
+		})
+	}
+}
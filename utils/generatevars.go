@@ -5,42 +5,229 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"slices"
+	"sort"
 	"strings"
+
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/zclconf/go-cty/cty"
 )
 
+// GenerateVarsByDims loads data of all the dimensions, resolves ${dim.data.key} references between them,
+// validates it against the schemas and only then writes var.iacconsole_<dim>_data and var.iacconsole_<dim>_name.
+// For multi dimensions var.iacconsole_<dim>_names list is written and var.iacconsole_<dim>_data is a map keyed by value
 func (s *State) GenerateVarsByDims() error {
-	for dimKey, dimValue := range s.ParsedDimensions {
-		dimensionJsonMap, err := s.GetDimData(dimKey, dimValue, false)
+	dimKeys := make([]string, 0, len(s.ParsedDimensions))
+	for dimKey := range s.ParsedDimensions {
+		dimKeys = append(dimKeys, dimKey)
+	}
+	sort.Strings(dimKeys)
+
+	dimensionsData := make(map[string]map[string]interface{}, len(dimKeys))
+	var validationErrors, sensitiveVars []string
+	for _, dimKey := range dimKeys {
+		var dimensionDefaults map[string]interface{}
+		if s.mergeDefaultsEnabled() {
+			var err error
+			if dimensionDefaults, err = s.GetDimData(dimKey, "dim_defaults", true); err != nil {
+				return err
+			}
+		}
+
+		valuesData := make(map[string]interface{})
+		for _, dimValue := range s.dimensionValues(dimKey) {
+			dimensionJsonMap, hasSecrets, err := s.loadDimensionValue(dimKey, dimValue, dimensionDefaults)
+			if err != nil {
+				return err
+			}
+			if hasSecrets && !slices.Contains(sensitiveVars, "iacconsole_"+dimKey+"_data") {
+				sensitiveVars = append(sensitiveVars, "iacconsole_"+dimKey+"_data")
+			}
+			valuesData[dimValue] = dimensionJsonMap
+			dimensionsData[dimKey] = dimensionJsonMap
+		}
+		if _, ok := s.MultiDimensions[dimKey]; ok {
+			dimensionsData[dimKey] = valuesData
+		}
+	}
+
+	dimReferences, err := s.interpolateDimensions(dimensionsData)
+	if err != nil {
+		return err
+	}
+	// data with values of the sensitive dimension is sensitive too, even through other dimensions
+	for changed := true; changed; {
+		changed = false
+		for dimKey, referencedDimKeys := range dimReferences {
+			for _, referencedDimKey := range referencedDimKeys {
+				if slices.Contains(sensitiveVars, "iacconsole_"+referencedDimKey+"_data") && !slices.Contains(sensitiveVars, "iacconsole_"+dimKey+"_data") {
+					sensitiveVars = append(sensitiveVars, "iacconsole_"+dimKey+"_data")
+					changed = true
+				}
+			}
+		}
+	}
+
+	for _, dimKey := range dimKeys {
+		dimSchemas, err := s.loadDimSchemas(dimKey)
 		if err != nil {
 			return err
 		}
+		dimension, _ := s.UnitManifest.Dimension(dimKey)
+		for _, dimValue := range s.dimensionValues(dimKey) {
+			dimensionJsonMap := dimensionsData[dimKey]
+			if _, ok := s.MultiDimensions[dimKey]; ok {
+				dimensionJsonMap, _ = dimensionJsonMap[dimValue].(map[string]interface{})
+			}
+			// errors quote the invalid values, which could be resolved secrets
+			for _, validationError := range validateDimData(dimSchemas, dimensionJsonMap) {
+				validationErrors = append(validationErrors, dimKey+"/"+dimValue+": "+MaskSecrets(validationError, s.SensitiveValues))
+			}
+			if dimension.dataType != cty.NilType {
+				if err := checkHclType(dimensionJsonMap, dimension.dataType); err != nil {
+					validationErrors = append(validationErrors, dimKey+"/"+dimValue+": type "+dimension.Type+": "+MaskSecrets(err.Error(), s.SensitiveValues))
+				}
+			}
+		}
+	}
+	if len(validationErrors) > 0 {
+		return fmt.Errorf("dimension data does not match schema or type:\n%s", strings.Join(validationErrors, "\n"))
+	}
 
+	inventorySource := s.inventorySource()
+	for _, dimKey := range dimKeys {
+		dimension, _ := s.UnitManifest.Dimension(dimKey)
+		dataDeclaration := tfVarDeclaration{
+			Description: describeDimensionVar(dimension, "Data of dimension "+dimKey+" value "+s.ParsedDimensions[dimKey]+" from "+inventorySource),
+			Sensitive:   slices.Contains(sensitiveVars, "iacconsole_"+dimKey+"_data"),
+		}
+		if dimension.dataType != cty.NilType {
+			dataDeclaration.Type = typeexpr.TypeString(dimension.dataType)
+		}
+		namesVar := "iacconsole_" + dimKey + "_name"
+		namesDeclaration := tfVarDeclaration{Type: "string", Description: "Value of dimension " + dimKey + " in " + inventorySource}
 		targetAutoTfvarMap := map[string]interface{}{
-			"iacconsole_" + dimKey + "_data": dimensionJsonMap,
-			"iacconsole_" + dimKey + "_name": dimValue,
+			"iacconsole_" + dimKey + "_data": dimensionsData[dimKey],
+		}
+
+		if dimValues, ok := s.MultiDimensions[dimKey]; ok {
+			namesVar = "iacconsole_" + dimKey + "_names"
+			namesDeclaration = tfVarDeclaration{Type: "list(string)", Description: "Values of dimension " + dimKey + " in " + inventorySource}
+			targetAutoTfvarMap[namesVar] = dimValues
+			dataDeclaration.Description = describeDimensionVar(dimension, "Data of dimension "+dimKey+" values "+strings.Join(dimValues, ", ")+" by value from "+inventorySource)
+			if dimension.dataType != cty.NilType {
+				dataDeclaration.Type = typeexpr.TypeString(cty.Map(dimension.dataType))
+			} else {
+				dataDeclaration.Type = typeexpr.TypeString(inferHclMapType(dimensionsData[dimKey]))
+			}
+		} else {
+			targetAutoTfvarMap[namesVar] = s.ParsedDimensions[dimKey]
 		}
 
-		if err := writeTfvarsMaps(targetAutoTfvarMap, dimKey, s.CmdWorkTempDir); err != nil {
+		declarations := map[string]tfVarDeclaration{"iacconsole_" + dimKey + "_data": dataDeclaration, namesVar: namesDeclaration}
+		if err := writeTfvarsMaps(targetAutoTfvarMap, dimKey, s.CmdWorkTempDir, declarations); err != nil {
 			return err
 		}
-		log.Println("attached dimension in var.iacconsole_" + dimKey + "_data and var.iacconsole_" + dimKey + "_name")
+		log.Println("attached dimension in var.iacconsole_" + dimKey + "_data and var." + namesVar)
+	}
 
+	// optional dimensions not passed are null, so tf-code could check them
+	for _, dimKey := range s.OptionalDimensions() {
+		dimension, _ := s.UnitManifest.Dimension(dimKey)
+		namesVar := "iacconsole_" + dimKey + "_name"
+		dataDeclaration := tfVarDeclaration{Type: "any", Description: describeDimensionVar(dimension, "Data of optional dimension "+dimKey+" from "+inventorySource+", null if not passed")}
+		namesDeclaration := tfVarDeclaration{Type: "string", Description: "Value of optional dimension " + dimKey + ", null if not passed"}
+		if dimension.dataType != cty.NilType {
+			dataDeclaration.Type = typeexpr.TypeString(dimension.dataType)
+		}
+		if s.UnitManifest.IsMultiDimension(dimKey) {
+			namesVar = "iacconsole_" + dimKey + "_names"
+			namesDeclaration = tfVarDeclaration{Type: "list(string)", Description: "Values of optional dimension " + dimKey + ", null if not passed"}
+			if dimension.dataType != cty.NilType {
+				dataDeclaration.Type = typeexpr.TypeString(cty.Map(dimension.dataType))
+			}
+		}
+		targetAutoTfvarMap := map[string]interface{}{
+			"iacconsole_" + dimKey + "_data": nil,
+			namesVar:                         nil,
+		}
+		declarations := map[string]tfVarDeclaration{"iacconsole_" + dimKey + "_data": dataDeclaration, namesVar: namesDeclaration}
+		if err := writeTfvarsMaps(targetAutoTfvarMap, dimKey, s.CmdWorkTempDir, declarations); err != nil {
+			return err
+		}
+		log.Println("optional dimension " + dimKey + " not passed, var.iacconsole_" + dimKey + "_data and var." + namesVar + " are null")
 	}
 	return nil
 }
 
+// describeDimensionVar returns description of the variable, prefixed with description of the dimension from the unit manifest
+func describeDimensionVar(dimension unitDimension, description string) string {
+	if dimension.Description == "" {
+		return description
+	}
+	return strings.TrimSuffix(dimension.Description, ".") + ". " + description
+}
+
+// dimensionValues returns all the values of multi dimension or the only value of the dimension
+func (s *State) dimensionValues(dimKey string) []string {
+	if dimValues, ok := s.MultiDimensions[dimKey]; ok {
+		return dimValues
+	}
+	return []string{s.ParsedDimensions[dimKey]}
+}
+
+// loadDimensionValue returns data of the dimension value merged with dimensionDefaults and secrets resolved,
+// hasSecrets is true if any secret was resolved
+func (s *State) loadDimensionValue(dimKey string, dimValue string, dimensionDefaults map[string]interface{}) (map[string]interface{}, bool, error) {
+	dimensionJsonMap, err := s.GetDimData(dimKey, dimValue, false)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(dimensionDefaults) > 0 {
+		dimensionJsonMap = mergeDimData(dimensionDefaults, dimensionJsonMap)
+		log.Println("merged dim_defaults into " + dimKey + "/" + dimValue)
+	}
+	hasSecrets, err := s.resolveSecretRefs(dimensionJsonMap)
+	if err != nil {
+		return nil, false, fmt.Errorf("dimension %s/%s: %v", dimKey, dimValue, err)
+	}
+	if err := s.collectSensitiveValues(dimKey+"/"+dimValue, dimensionJsonMap); err != nil {
+		return nil, false, fmt.Errorf("dimension %s/%s: %v", dimKey, dimValue, err)
+	}
+	return dimensionJsonMap, hasSecrets, nil
+}
+
+// mergeDefaultsEnabled returns merge_defaults of the unit manifest if set, otherwise merge_defaults of the org config
+func (s *State) mergeDefaultsEnabled() bool {
+	if s.UnitManifest.MergeDefaults != nil {
+		return *s.UnitManifest.MergeDefaults
+	}
+	return s.GetBoolFromViperByOrgOrDefault("merge_defaults")
+}
+
 func (s *State) GenerateVarsByDimOptional(optionType string) error {
 	for dimKey := range s.ParsedDimensions {
 		dimensionJsonMap, err := s.GetDimData(dimKey, "dim_"+optionType, true)
 		if err != nil {
 			return err
 		}
+		hasSecrets, err := s.resolveSecretRefs(dimensionJsonMap)
+		if err != nil {
+			return fmt.Errorf("dimension %s/dim_%s: %v", dimKey, optionType, err)
+		}
+		if err := s.collectSensitiveValues(dimKey+"/dim_"+optionType, dimensionJsonMap); err != nil {
+			return fmt.Errorf("dimension %s/dim_%s: %v", dimKey, optionType, err)
+		}
 		if len(dimensionJsonMap) > 0 {
+			varName := "iacconsole_" + dimKey + "_" + optionType
 			targetAutoTfvarMap := map[string]interface{}{
-				"iacconsole_" + dimKey + "_" + optionType: dimensionJsonMap,
+				varName: dimensionJsonMap,
+			}
+			declarations := map[string]tfVarDeclaration{
+				varName: {Description: "dim_" + optionType + " of dimension " + dimKey + " from " + s.inventorySource(), Sensitive: hasSecrets},
 			}
 
-			if err := writeTfvarsMaps(targetAutoTfvarMap, dimKey+"_"+optionType, s.CmdWorkTempDir); err != nil {
+			if err := writeTfvarsMaps(targetAutoTfvarMap, dimKey+"_"+optionType, s.CmdWorkTempDir, declarations); err != nil {
 				return err
 			}
 			log.Println("attached " + optionType + " in var.iacconsole_" + dimKey + "_" + optionType)
@@ -53,7 +240,7 @@ func (s *State) GenerateVarsByDimAndData(optionType string, dimKey string, dimen
 	targetAutoTfvarMap := map[string]interface{}{
 		"iacconsole_" + dimKey + "_" + optionType: dimensionJsonMap,
 	}
-	if err := writeTfvarsMaps(targetAutoTfvarMap, dimKey+"_"+optionType, s.CmdWorkTempDir); err != nil {
+	if err := writeTfvarsMaps(targetAutoTfvarMap, dimKey+"_"+optionType, s.CmdWorkTempDir, nil); err != nil {
 		return err
 	}
 	log.Println("attached " + optionType + " in var.iacconsole_" + dimKey + "_" + optionType)
@@ -62,37 +249,55 @@ func (s *State) GenerateVarsByDimAndData(optionType string, dimKey string, dimen
 
 func (s *State) GenerateVarsByEnvVars() error {
 	targetAutoTfvarMap := make(map[string]interface{})
+	declarations := make(map[string]tfVarDeclaration)
 
 	for _, envVar := range os.Environ() {
 		if strings.HasPrefix(envVar, "iacconsole_envvar_") {
 			envVarList := strings.SplitN(envVar, "=", 2)
 			targetAutoTfvarMap[envVarList[0]] = envVarList[1]
+			declarations[envVarList[0]] = tfVarDeclaration{Description: "Environment variable " + envVarList[0]}
+			if slices.Contains(s.UnitManifest.SensitiveKeys, envVarList[0]) || slices.Contains(s.UnitManifest.SensitiveKeys, strings.TrimPrefix(envVarList[0], "iacconsole_envvar_")) {
+				s.addSensitiveValue(envVarList[0], envVarList[1])
+			}
 			log.Println("attached env variable in var." + envVarList[0])
 		}
 	}
 
 	if len(targetAutoTfvarMap) > 0 {
-		if err := writeTfvarsMaps(targetAutoTfvarMap, "envivars", s.CmdWorkTempDir); err != nil {
+		if err := writeTfvarsMaps(targetAutoTfvarMap, "envivars", s.CmdWorkTempDir, declarations); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func writeTfvarsMaps(targetAutoTfvarMap map[string]interface{}, fileName string, cmdWorkTempDir string) error {
+// tfVarDeclaration is declaration of the variable written by writeTfvarsMaps, Type is inferred from the value if not set
+type tfVarDeclaration struct {
+	Type        string
+	Description string
+	Sensitive   bool
+}
+
+// writeTfvarsMaps writes variables declarations and values
+func writeTfvarsMaps(targetAutoTfvarMap map[string]interface{}, fileName string, cmdWorkTempDir string, declarations map[string]tfVarDeclaration) error {
 	targetVarsTfPath := cmdWorkTempDir + "/iacconsole_" + fileName + "_vars.tf.json"
 	targetAutoTfvarsPath := cmdWorkTempDir + "/iacconsole_" + fileName + ".auto.tfvars.json"
 
 	targetVarsTfMap := make(map[string]interface{})
 
 	for key, value := range targetAutoTfvarMap {
-		switch value.(type) {
-		case string:
-			targetVarsTfMap[key] = map[string]string{"type": "string"}
-		default:
-			targetVarsTfMap[key] = map[string]interface{}{}
+		declaration := declarations[key]
+		if declaration.Type == "" {
+			declaration.Type = typeexpr.TypeString(inferHclType(value))
 		}
-
+		varDeclaration := map[string]interface{}{"type": declaration.Type}
+		if declaration.Description != "" {
+			varDeclaration["description"] = declaration.Description
+		}
+		if declaration.Sensitive {
+			varDeclaration["sensitive"] = true
+		}
+		targetVarsTfMap[key] = varDeclaration
 	}
 
 	targetVarsTfMapFull := map[string]interface{}{
@@ -113,9 +318,13 @@ func marshalJsonAndWrite(jsonMap map[string]interface{}, jsonPath string) error
 	if err != nil {
 		return fmt.Errorf("failed to marshal json: %v", err)
 	}
-	err = os.WriteFile(jsonPath, targetAutoTfvarMapBytes, os.ModePerm)
+	// tfvars could contain resolved secrets, so they are readable only by the user
+	err = os.WriteFile(jsonPath, targetAutoTfvarMapBytes, 0600)
 	if err != nil {
 		return fmt.Errorf("error writing file: %v", err)
 	}
+	if err := os.Chmod(jsonPath, 0600); err != nil {
+		return fmt.Errorf("error writing file: %v", err)
+	}
 	return nil
 }
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestGenerateVarsByDimsMergeDefaults(t *testing.T) {
+	provider := fakeInventoryProvider{
+		"account/dim_defaults": `{"region": "eu", "tags": {"team": "infra", "env": "none"}, "azs": ["a", "b"]}`,
+		"account/dev":          `{"tags": {"env": "dev"}, "azs": ["c"]}`,
+		"region/eu":            `{"name": "eu-west-1"}`,
+		"region/us":            `{"name": "us-east-1"}`,
+	}
+	enabled, disabled := true, false
+
+	tests := []struct {
+		name          string
+		orgConfig     interface{}
+		mergeDefaults *bool
+		want          map[string]interface{}
+	}{
+		{
+			name: "disabled by default",
+			want: map[string]interface{}{"tags": map[string]interface{}{"env": "dev"}, "azs": []interface{}{"c"}},
+		},
+		{
+			name:      "enabled in org config",
+			orgConfig: true,
+			want:      map[string]interface{}{"region": "eu", "tags": map[string]interface{}{"team": "infra", "env": "dev"}, "azs": []interface{}{"c"}},
+		},
+		{
+			name:          "unit manifest overrides org config",
+			orgConfig:     true,
+			mergeDefaults: &disabled,
+			want:          map[string]interface{}{"tags": map[string]interface{}{"env": "dev"}, "azs": []interface{}{"c"}},
+		},
+		{
+			name:          "enabled in unit manifest",
+			mergeDefaults: &enabled,
+			want:          map[string]interface{}{"region": "eu", "tags": map[string]interface{}{"team": "infra", "env": "dev"}, "azs": []interface{}{"c"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set("merge-org.merge_defaults", tt.orgConfig)
+			defer viper.Set("merge-org.merge_defaults", nil)
+
+			s := &State{
+				OrgName:           "merge-org",
+				CmdWorkTempDir:    t.TempDir(),
+				UnitManifest:      unitManifestStruct{Dimensions: []unitDimension{{Name: "account"}, {Name: "region"}}, MergeDefaults: tt.mergeDefaults},
+				ParsedDimensions:  map[string]string{"account": "dev", "region": "us"},
+				inventoryProvider: provider,
+			}
+			if err := s.GenerateVarsByDims(); err != nil {
+				t.Fatal(err)
+			}
+
+			for dimKey, want := range map[string]map[string]interface{}{"account": tt.want, "region": {"name": "us-east-1"}} {
+				tfvarsBytes, err := os.ReadFile(filepath.Join(s.CmdWorkTempDir, "iacconsole_"+dimKey+".auto.tfvars.json"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				var tfvars map[string]interface{}
+				if err := json.Unmarshal(tfvarsBytes, &tfvars); err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(tfvars["iacconsole_"+dimKey+"_data"], want) {
+					t.Errorf("iacconsole_%s_data = %v, want %v", dimKey, tfvars["iacconsole_"+dimKey+"_data"], want)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateVarsByDimsMasksSecretsInValidationErrors(t *testing.T) {
+	t.Setenv("IACCONSOLE_TEST_DB_PASSWORD", "SuperSecret123")
+	t.Setenv("IACCONSOLE_TEST_PIN", "42")
+	provider := fakeInventoryProvider{
+		"account/dim_schema": `{"properties": {"pw": {"pattern": "^x$"}, "pin": {"enum": ["1"]}, "nested": {"items": {"type": "number"}}}}`,
+		"account/dev":        `{"pw": {"$secret": "env:IACCONSOLE_TEST_DB_PASSWORD"}, "pin": {"$secret": "env:IACCONSOLE_TEST_PIN"}, "nested": [{"$secret": "env:IACCONSOLE_TEST_DB_PASSWORD"}]}`,
+	}
+
+	s := &State{
+		OrgName:           "secret-org",
+		CmdWorkTempDir:    t.TempDir(),
+		UnitManifest:      unitManifestStruct{Dimensions: []unitDimension{{Name: "account", Type: "object({pw = number})"}}},
+		ParsedDimensions:  map[string]string{"account": "dev"},
+		inventoryProvider: provider,
+	}
+	var err error
+	if s.UnitManifest.Dimensions[0].dataType, err = parseHclType(s.UnitManifest.Dimensions[0].Type); err != nil {
+		t.Fatal(err)
+	}
+	err = s.GenerateVarsByDims()
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{"account/dev: /pw: '***' does not match pattern", "account/dev: /pin: value must be", "account/dev: type object({pw = number}): /pw"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error containing %q, got:\n%v", want, err)
+		}
+	}
+	for _, secret := range []string{"SuperSecret123", "'42'", "\"42\""} {
+		if strings.Contains(err.Error(), secret) {
+			t.Errorf("secret %s in error:\n%v", secret, err)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// inferHclType returns type constraint of the value decoded from JSON: objects for maps with attribute names as keys,
+// lists for elements of the same type, tuples otherwise and any for null
+func inferHclType(value interface{}) cty.Type {
+	switch value := value.(type) {
+	case string:
+		return cty.String
+	case bool:
+		return cty.Bool
+	case float64, float32, int, int32, int64, uint64, json.Number:
+		return cty.Number
+	case map[string]interface{}:
+		attributeTypes := make(map[string]cty.Type, len(value))
+		validNames := true
+		for key, child := range value {
+			attributeTypes[key] = inferHclType(child)
+			validNames = validNames && validHclAttributeName(key)
+		}
+		if validNames {
+			return cty.Object(attributeTypes)
+		}
+		// keys like 10.0.0.0/16 or for can not be object attributes
+		if elementType, ok := commonHclType(slices.Collect(maps.Values(attributeTypes))); ok {
+			return cty.Map(elementType)
+		}
+		return cty.DynamicPseudoType
+	case []interface{}:
+		elementTypes := make([]cty.Type, 0, len(value))
+		for _, child := range value {
+			elementTypes = append(elementTypes, inferHclType(child))
+		}
+		if len(elementTypes) == 0 {
+			return cty.List(cty.DynamicPseudoType)
+		}
+		if elementType, ok := commonHclType(elementTypes); ok && elementType != cty.DynamicPseudoType {
+			return cty.List(elementType)
+		}
+		return cty.Tuple(elementTypes)
+	}
+	return cty.DynamicPseudoType
+}
+
+// hclKeywords are valid identifiers, but break the object type constraint when used as attribute names
+var hclKeywords = []string{"for", "in", "if", "else", "endfor", "endif", "true", "false", "null"}
+
+// validHclAttributeName returns true if the key can be written as attribute name of object type constraint
+func validHclAttributeName(key string) bool {
+	return hclsyntax.ValidIdentifier(key) && !slices.Contains(hclKeywords, key)
+}
+
+// inferHclMapType returns map type of the values if all of them have the same type, inferred type of the whole map otherwise
+func inferHclMapType(values map[string]interface{}) cty.Type {
+	elementTypes := make([]cty.Type, 0, len(values))
+	for _, value := range values {
+		elementTypes = append(elementTypes, inferHclType(value))
+	}
+	if elementType, ok := commonHclType(elementTypes); ok && len(values) > 0 {
+		return cty.Map(elementType)
+	}
+	return inferHclType(values)
+}
+
+// commonHclType returns the type if all the types are equal
+func commonHclType(types []cty.Type) (cty.Type, bool) {
+	if len(types) == 0 {
+		return cty.DynamicPseudoType, true
+	}
+	for _, elementType := range types[1:] {
+		if !elementType.Equals(types[0]) {
+			return cty.NilType, false
+		}
+	}
+	return types[0], true
+}
+
+// parseHclType parses type constraint like object({cidr = string})
+func parseHclType(typeExpr string) (cty.Type, error) {
+	expr, diags := hclsyntax.ParseExpression([]byte(typeExpr), "type", hcl.InitialPos)
+	if diags.HasErrors() {
+		return cty.NilType, fmt.Errorf("invalid type %q: %s", typeExpr, diags.Error())
+	}
+	ty, diags := typeexpr.TypeConstraint(expr)
+	if diags.HasErrors() {
+		return cty.NilType, fmt.Errorf("invalid type %q: %s", typeExpr, diags.Error())
+	}
+	return ty, nil
+}
+
+// checkHclType returns error if the value can not be converted to the type, like OpenTofu does for variables
+func checkHclType(value interface{}, ty cty.Type) error {
+	valueJsonBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	impliedType, err := ctyjson.ImpliedType(valueJsonBytes)
+	if err != nil {
+		return err
+	}
+	ctyValue, err := ctyjson.Unmarshal(valueJsonBytes, impliedType)
+	if err != nil {
+		return err
+	}
+	if _, err := convert.Convert(ctyValue, ty); err != nil {
+		var pathErr cty.PathError
+		if errors.As(err, &pathErr) && len(pathErr.Path) > 0 {
+			return fmt.Errorf("%s: %v", formatCtyPath(pathErr.Path), err)
+		}
+		return err
+	}
+	return nil
+}
+
+// formatCtyPath formats path like /vpc/subnets/0, the same way as schema validation errors
+func formatCtyPath(path cty.Path) string {
+	var builder strings.Builder
+	for _, step := range path {
+		switch step := step.(type) {
+		case cty.GetAttrStep:
+			builder.WriteString("/" + step.Name)
+		case cty.IndexStep:
+			if step.Key.Type() == cty.String {
+				builder.WriteString("/" + step.Key.AsString())
+			} else {
+				builder.WriteString("/" + step.Key.AsBigFloat().String())
+			}
+		}
+	}
+	return builder.String()
+}
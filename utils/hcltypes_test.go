@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+)
+
+func TestInferHclType(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "string", value: `"a"`, want: "string"},
+		{name: "null", value: `null`, want: "any"},
+		{name: "list of the same type", value: `[1, 2]`, want: "list(number)"},
+		{name: "empty list", value: `[]`, want: "list(any)"},
+		{name: "tuple", value: `["a", 1]`, want: "tuple([string,number])"},
+		{name: "object", value: `{"cidr": "10.0.0.0/16", "tags": {"env": "dev"}}`, want: "object({cidr=string,tags=object({env=string})})"},
+		{name: "keys which are not identifiers", value: `{"10.0.0.0/16": "a", "10.1.0.0/16": "b"}`, want: "map(string)"},
+		{name: "keys which are not identifiers with different types", value: `{"a b": "a", "c d": 1}`, want: "any"},
+		{name: "for keyword", value: `{"for": "a", "name": "b"}`, want: "map(string)"},
+		{name: "in keyword", value: `{"in": 1, "out": 2}`, want: "map(number)"},
+		{name: "if keyword", value: `{"if": "a"}`, want: "map(string)"},
+		{name: "else keyword", value: `{"else": "a"}`, want: "map(string)"},
+		{name: "endfor keyword", value: `{"endfor": "a"}`, want: "map(string)"},
+		{name: "endif keyword", value: `{"endif": "a"}`, want: "map(string)"},
+		{name: "true keyword", value: `{"true": "a"}`, want: "map(string)"},
+		{name: "false keyword", value: `{"false": "a"}`, want: "map(string)"},
+		{name: "null keyword with different types", value: `{"null": "a", "other": true}`, want: "any"},
+		{name: "keyword in nested object", value: `{"rules": {"if": "a"}}`, want: "object({rules=map(string)})"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value interface{}
+			if err := json.Unmarshal([]byte(tt.value), &value); err != nil {
+				t.Fatal(err)
+			}
+			got := typeexpr.TypeString(inferHclType(value))
+			if got != tt.want {
+				t.Fatalf("type = %s, want %s", got, tt.want)
+			}
+			// the declared type must be accepted by OpenTofu and the value must conform to it
+			ty, err := parseHclType(got)
+			if err != nil {
+				t.Fatalf("inferred type is not parsed: %v", err)
+			}
+			if err := checkHclType(value, ty); err != nil {
+				t.Errorf("value does not conform to inferred type: %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckHclType(t *testing.T) {
+	tests := []struct {
+		name     string
+		typeExpr string
+		value    string
+		wantErr  string
+	}{
+		{name: "object", typeExpr: "object({cidr = string, azs = list(string)})", value: `{"cidr": "10.0.0.0/16", "azs": ["a"], "extra": 1}`},
+		{name: "number as string", typeExpr: "string", value: `1`},
+		{name: "optional attribute", typeExpr: "object({cidr = string, azs = optional(list(string))})", value: `{"cidr": "10.0.0.0/16"}`},
+		{name: "missing attribute", typeExpr: "object({cidr = string})", value: `{"other": "a"}`, wantErr: `attribute "cidr" is required`},
+		{name: "nested path in error", typeExpr: "object({subnets = list(number)})", value: `{"subnets": [1, "a"]}`, wantErr: "/subnets/1: a number is required"},
+		{name: "invalid type expression", typeExpr: "object({cidr = strng})", wantErr: `invalid type "object({cidr = strng})"`},
+		{name: "invalid syntax", typeExpr: "object({", wantErr: `invalid type "object({"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ty, err := parseHclType(tt.typeExpr)
+			if err == nil {
+				var value interface{}
+				if err := json.Unmarshal([]byte(tt.value), &value); err != nil {
+					t.Fatal(err)
+				}
+				err = checkHclType(value, ty)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
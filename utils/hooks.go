@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RunHooks executes <hookType>_<action> hooks (like before_init or after_apply) from config and then from unit manifest.
+// Hooks are executed by sh in CmdWorkTempDir, exitCode is provided to the after hooks
+func (s *State) RunHooks(hookType string, action string, exitCode int, stdout io.Writer, stderr io.Writer) error {
+	hookName := hookType + "_" + action
+	hookCommands := hooksToList(s.GetObjectFromViperByOrgOrDefault("hooks")[hookName])
+	hookCommands = append(hookCommands, s.UnitManifest.Hooks[hookName]...)
+	if len(hookCommands) == 0 {
+		return nil
+	}
+
+	hookEnv := append(os.Environ(), s.hookEnvVars(action)...)
+	if hookType == "after" {
+		hookEnv = append(hookEnv, "IACCONSOLE_EXIT_CODE="+strconv.Itoa(exitCode))
+	}
+
+	for _, hookCommand := range hookCommands {
+		log.Println("iacconsole executing " + hookName + " hook: " + hookCommand)
+		hook := exec.Command("sh", "-c", hookCommand)
+		hook.Dir = s.CmdWorkTempDir
+		hook.Env = hookEnv
+		hook.Stdout = stdout
+		hook.Stderr = stderr
+		if err := hook.Run(); err != nil {
+			return fmt.Errorf("%s hook %q failed: %v", hookName, hookCommand, err)
+		}
+	}
+	return nil
+}
+
+// hookEnvVars returns env variables describing the target of the execution for hooks
+func (s *State) hookEnvVars(action string) []string {
+	dimKeys := make([]string, 0, len(s.ParsedDimensions))
+	for dimKey := range s.ParsedDimensions {
+		dimKeys = append(dimKeys, dimKey)
+	}
+	sort.Strings(dimKeys)
+
+	dimensions := make([]string, 0, len(dimKeys))
+	envVars := []string{
+		"IACCONSOLE_ACTION=" + action,
+		"IACCONSOLE_ORG=" + s.OrgName,
+		"IACCONSOLE_UNIT=" + s.UnitName,
+		"IACCONSOLE_WORKSPACE=" + s.Workspace,
+		"IACCONSOLE_STATE_PATH=" + s.StateS3Path,
+	}
+	for _, dimKey := range dimKeys {
+		// values of multi dimension are joined with + like in the state path, so , separates only dimensions
+		if dimValues, ok := s.MultiDimensions[dimKey]; ok {
+			dimensions = append(dimensions, dimKey+":"+strings.Join(dimValues, "+"))
+		} else {
+			dimensions = append(dimensions, dimKey+":"+s.ParsedDimensions[dimKey])
+		}
+		envVars = append(envVars, "IACCONSOLE_DIM_"+strings.ToUpper(nonVarNameChars.ReplaceAllString(dimKey, "_"))+"="+s.ParsedDimensions[dimKey])
+	}
+	envVars = append(envVars, "IACCONSOLE_DIMENSIONS="+strings.Join(dimensions, ","))
+
+	return envVars
+}
+
+// hooksToList converts hook value from config, a single command or a list of commands, to a list
+func hooksToList(hookValue any) []string {
+	switch hookValue := hookValue.(type) {
+	case string:
+		return []string{hookValue}
+	case []any:
+		hookCommands := make([]string, 0, len(hookValue))
+		for _, hookCommand := range hookValue {
+			hookCommands = append(hookCommands, fmt.Sprint(hookCommand))
+		}
+		return hookCommands
+	case []string:
+		return hookValue
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHookEnvVars(t *testing.T) {
+	tests := []struct {
+		name            string
+		dimensions      map[string]string
+		multiDimensions map[string][]string
+		want            []string
+	}{
+		{
+			name:       "single values",
+			dimensions: map[string]string{"datacenter": "staging1", "account": "test-account"},
+			want: []string{
+				"IACCONSOLE_DIM_ACCOUNT=test-account",
+				"IACCONSOLE_DIM_DATACENTER=staging1",
+				"IACCONSOLE_DIMENSIONS=account:test-account,datacenter:staging1",
+			},
+		},
+		{
+			name:            "multi dimension values are joined with +",
+			dimensions:      map[string]string{"account": "dev", "peer-dc": "dc1,dc2"},
+			multiDimensions: map[string][]string{"peer-dc": {"dc1", "dc2"}},
+			want: []string{
+				"IACCONSOLE_DIM_ACCOUNT=dev",
+				"IACCONSOLE_DIM_PEER_DC=dc1,dc2",
+				"IACCONSOLE_DIMENSIONS=account:dev,peer-dc:dc1+dc2",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &State{OrgName: "org", UnitName: "vpc", Workspace: "master", StateS3Path: "org_org/vpc.tfstate", ParsedDimensions: tt.dimensions, MultiDimensions: tt.multiDimensions}
+			want := append([]string{
+				"IACCONSOLE_ACTION=plan",
+				"IACCONSOLE_ORG=org",
+				"IACCONSOLE_UNIT=vpc",
+				"IACCONSOLE_WORKSPACE=master",
+				"IACCONSOLE_STATE_PATH=org_org/vpc.tfstate",
+			}, tt.want...)
+			if got := s.hookEnvVars("plan"); !reflect.DeepEqual(got, want) {
+				t.Errorf("env = %v, want %v", got, want)
+			}
+		})
+	}
+}
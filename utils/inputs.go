@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+)
+
+var nonVarNameChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+type tfStateOutputs struct {
+	Outputs map[string]struct {
+		Value interface{} `json:"value"`
+	} `json:"outputs"`
+}
+
+// GenerateVarsByInputsFrom reads outputs of the units declared in inputs_from of the unit manifest
+// and attaches them in var.iacconsole_input_<unit>
+func (s *State) GenerateVarsByInputsFrom() error {
+	for _, input := range s.UnitManifest.InputsFrom {
+		outputs, err := s.getUnitOutputs(input.Unit)
+		if err != nil {
+			return err
+		}
+
+		inputMap := make(map[string]interface{}, len(input.Outputs))
+		for _, outputName := range input.Outputs {
+			output, ok := outputs.Outputs[outputName]
+			if !ok {
+				return fmt.Errorf("output %s not found in state of unit %s", outputName, input.Unit)
+			}
+			inputMap[outputName] = output.Value
+		}
+
+		inputVarName := nonVarNameChars.ReplaceAllString(input.Unit, "_")
+		if err := s.GenerateVarsByDimAndData(inputVarName, "input", inputMap); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GenerateVarsByInputsFromPlaceholders attaches null values for outputs of the units declared in inputs_from
+// without reading their state, for render which never executes OpenTofu
+func (s *State) GenerateVarsByInputsFromPlaceholders() error {
+	for _, input := range s.UnitManifest.InputsFrom {
+		inputMap := make(map[string]interface{}, len(input.Outputs))
+		for _, outputName := range input.Outputs {
+			inputMap[outputName] = nil
+		}
+
+		inputVarName := nonVarNameChars.ReplaceAllString(input.Unit, "_")
+		log.Printf("warning: state of inputs_from unit %s is not read, outputs in var.iacconsole_input_%s are null", input.Unit, inputVarName)
+		if err := s.GenerateVarsByDimAndData(inputVarName, "input", inputMap); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getUnitOutputs prepares producer unit with the same org, dimensions and workspace in a separate temp dir
+// (not the one used to run the producer, which is never initialized, cleaned or locked here) and pulls outputs from its state
+func (s *State) getUnitOutputs(unitName string) (tfStateOutputs, error) {
+	var outputs tfStateOutputs
+
+	producer := &State{
+		UnitName:          unitName,
+		OrgName:           s.OrgName,
+		SharedModulesPath: s.SharedModulesPath,
+		InventoryPath:     s.InventoryPath,
+		ApiEndpoint:       s.ApiEndpoint,
+		Workspace:         s.Workspace,
+		Verbose:           s.Verbose,
+		Offline:           s.Offline,
+		FailOnLock:        s.FailOnLock,
+		tempDirPurpose:    "outputs",
+	}
+	producer.UnitPath, _ = filepath.Abs(s.GetStringFromViperByOrgOrDefault("units_path") + "/" + s.OrgName + "/" + unitName)
+
+	unitManifest, err := ReadUnitManifest(producer.UnitPath + "/unit_manifest.json")
+	if err != nil {
+		return outputs, fmt.Errorf("inputs_from unit %s: %v", unitName, err)
+	}
+	producer.UnitManifest = unitManifest
+	if producer.DimensionsFlags, err = s.producerDimensionsFlags(unitManifest); err != nil {
+		return outputs, fmt.Errorf("inputs_from unit %s: %v", unitName, err)
+	}
+	if err := producer.ParseDimensions(); err != nil {
+		return outputs, fmt.Errorf("inputs_from unit %s: %v", unitName, err)
+	}
+
+	backendConfig := producer.SetupBackendConfig()
+	if err := producer.PrepareTemp(); err != nil {
+		return outputs, fmt.Errorf("inputs_from unit %s: %v", unitName, err)
+	}
+	defer producer.UnlockTemp()
+
+	cmdToExec := s.GetStringFromViperByOrgOrDefault("cmd_to_exec")
+	initArgs := []string{"init", "-input=false"}
+	for param, value := range backendConfig {
+		initArgs = append(initArgs, "-backend-config="+param+"="+value.(string))
+	}
+	log.Println("iacconsole reading outputs of unit " + unitName + " from state " + producer.StateS3Path)
+	if _, err := runInDir(producer.CmdWorkTempDir, cmdToExec, initArgs...); err != nil {
+		return outputs, fmt.Errorf("inputs_from unit %s: %v", unitName, err)
+	}
+
+	stateBytes, err := runInDir(producer.CmdWorkTempDir, cmdToExec, "state", "pull")
+	if err != nil {
+		return outputs, fmt.Errorf("inputs_from unit %s: %v", unitName, err)
+	}
+	if len(bytes.TrimSpace(stateBytes)) == 0 {
+		return outputs, fmt.Errorf("inputs_from unit %s: state not found at %s", unitName, producer.StateS3Path)
+	}
+	if err := json.Unmarshal(stateBytes, &outputs); err != nil {
+		return outputs, fmt.Errorf("inputs_from unit %s: failed to parse state %s: %v", unitName, producer.StateS3Path, err)
+	}
+	return outputs, nil
+}
+
+// producerDimensionsFlags returns -d args of this unit for the dimensions declared by the producer unit,
+// defaults of this unit are not passed, the producer applies its own
+func (s *State) producerDimensionsFlags(producerManifest unitManifestStruct) ([]string, error) {
+	parsedDimArgs, err := parseDimArgs(s.DimensionsFlags)
+	if err != nil {
+		return nil, err
+	}
+	var dimensionsFlags []string
+	for _, dimKey := range slices.Sorted(maps.Keys(parsedDimArgs)) {
+		if _, ok := producerManifest.Dimension(dimKey); !ok {
+			continue
+		}
+		for _, value := range parsedDimArgs[dimKey] {
+			dimensionsFlags = append(dimensionsFlags, dimKey+":"+value)
+		}
+	}
+	return dimensionsFlags, nil
+}
+
+// runInDir executes command in dir and returns its stdout, stderr is returned in the error on failure
+func runInDir(dir string, name string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	child := exec.Command(name, args...)
+	child.Dir = dir
+	child.Env = os.Environ()
+	child.Stdout = &stdout
+	child.Stderr = &stderr
+	if err := child.Run(); err != nil {
+		return nil, fmt.Errorf("%s %s failed: %v\n%s", name, args[0], err, stderr.String())
+	}
+	return stdout.Bytes(), nil
+}
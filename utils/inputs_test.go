@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProducerDimensionsFlags(t *testing.T) {
+	tests := []struct {
+		name             string
+		args             []string
+		producerManifest unitManifestStruct
+		want             []string
+	}{
+		{
+			name:             "only dimensions declared by the producer",
+			args:             []string{"datacenter:eu1", "account:dev", "app:web"},
+			producerManifest: unitManifestStruct{Dimensions: []unitDimension{{Name: "account"}, {Name: "datacenter"}}},
+			want:             []string{"account:dev", "datacenter:eu1"},
+		},
+		{
+			name:             "all values of multi dimension",
+			args:             []string{"account:dev", "peer:dc2", "peer:dc1"},
+			producerManifest: unitManifestStruct{Dimensions: []unitDimension{{Name: "account"}, {Name: "peer"}}},
+			want:             []string{"account:dev", "peer:dc2", "peer:dc1"},
+		},
+		{
+			name:             "producer without dimensions",
+			args:             []string{"account:dev"},
+			producerManifest: unitManifestStruct{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// defaults of the consumer are in ParsedDimensions only, they must not be passed
+			s := &State{DimensionsFlags: tt.args, ParsedDimensions: map[string]string{"size": "small"}}
+			got, err := s.producerDimensionsFlags(tt.producerManifest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("dimensions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
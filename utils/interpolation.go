@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// interpolationExpr matches ${dim.name} and ${dim.data.key.nested} references, $${ is the escaped ${.
+// Anything else like ${aws:username} or ${var.name} in templates is kept as is
+var interpolationExpr = regexp.MustCompile(`\$\$\{|\$\{\s*([A-Za-z_][A-Za-z0-9_-]*\.(?:name|data(?:\.[^.}\s]+)*))\s*\}`)
+
+// dimInterpolator resolves references between dimensions data
+type dimInterpolator struct {
+	names map[string]string
+	data  map[string]map[string]interface{}
+	// references being resolved, to detect cycles
+	resolving []string
+	// dimensions referenced by every dimension
+	references map[string][]string
+}
+
+// interpolateDimensions replaces references in string values of all the dimensions data with the final values
+// and returns dimensions referenced by every dimension
+func (s *State) interpolateDimensions(dimensionsData map[string]map[string]interface{}) (map[string][]string, error) {
+	interpolator := &dimInterpolator{names: s.ParsedDimensions, data: dimensionsData, references: map[string][]string{}}
+
+	dimKeys := make([]string, 0, len(dimensionsData))
+	for dimKey := range dimensionsData {
+		dimKeys = append(dimKeys, dimKey)
+	}
+	sort.Strings(dimKeys)
+
+	interpolatedData := make(map[string]map[string]interface{}, len(dimensionsData))
+	for _, dimKey := range dimKeys {
+		interpolatedValue, err := interpolator.interpolate(dimKey, dimensionsData[dimKey], "")
+		if err != nil {
+			return nil, fmt.Errorf("dimension %s/%s: %v", dimKey, s.ParsedDimensions[dimKey], err)
+		}
+		interpolatedData[dimKey] = interpolatedValue.(map[string]interface{})
+	}
+	// references are resolved from the original data, so it is replaced only at the end
+	for dimKey, interpolatedValue := range interpolatedData {
+		dimensionsData[dimKey] = interpolatedValue
+	}
+	return interpolator.references, nil
+}
+
+// interpolate returns copy of the value with all the references resolved
+func (i *dimInterpolator) interpolate(dimKey string, value interface{}, path string) (interface{}, error) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		interpolatedMap := make(map[string]interface{}, len(value))
+		for key, child := range value {
+			interpolatedChild, err := i.interpolate(dimKey, child, path+"/"+key)
+			if err != nil {
+				return nil, err
+			}
+			interpolatedMap[key] = interpolatedChild
+		}
+		return interpolatedMap, nil
+	case []interface{}:
+		interpolatedList := make([]interface{}, len(value))
+		for index, child := range value {
+			interpolatedChild, err := i.interpolate(dimKey, child, path+"/"+strconv.Itoa(index))
+			if err != nil {
+				return nil, err
+			}
+			interpolatedList[index] = interpolatedChild
+		}
+		return interpolatedList, nil
+	case string:
+		return i.interpolateString(dimKey, value, path)
+	}
+	return value, nil
+}
+
+// interpolateString resolves references in the string. String with only one reference gets the referenced value as is
+// (number, list or object), otherwise referenced values are embedded into the string
+func (i *dimInterpolator) interpolateString(dimKey string, value string, path string) (interface{}, error) {
+	matches := interpolationExpr.FindAllStringSubmatchIndex(value, -1)
+	if len(matches) == 0 {
+		return value, nil
+	}
+
+	if len(matches) == 1 && matches[0][0] == 0 && matches[0][1] == len(value) && matches[0][2] >= 0 {
+		return i.resolveReference(dimKey, value[matches[0][2]:matches[0][3]], path)
+	}
+
+	var interpolated strings.Builder
+	lastEnd := 0
+	for _, match := range matches {
+		interpolated.WriteString(value[lastEnd:match[0]])
+		lastEnd = match[1]
+		if match[2] < 0 {
+			interpolated.WriteString("${")
+			continue
+		}
+
+		referencedValue, err := i.resolveReference(dimKey, value[match[2]:match[3]], path)
+		if err != nil {
+			return nil, err
+		}
+		switch referencedValue := referencedValue.(type) {
+		case string:
+			interpolated.WriteString(referencedValue)
+		case map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("%s: ${%s} is an object or list and can not be embedded into string", pathOrRoot(path), value[match[2]:match[3]])
+		default:
+			referencedJson, _ := json.Marshal(referencedValue)
+			interpolated.Write(referencedJson)
+		}
+	}
+	interpolated.WriteString(value[lastEnd:])
+	return interpolated.String(), nil
+}
+
+// resolveReference returns final value of reference like account.name or account.data.network.cidr
+func (i *dimInterpolator) resolveReference(dimKey string, reference string, path string) (interface{}, error) {
+	parts := strings.Split(reference, ".")
+
+	referencedDimKey := parts[0]
+	if _, ok := i.names[referencedDimKey]; !ok {
+		return nil, fmt.Errorf("%s: unknown dimension %q in ${%s}, passed dimensions: %s", pathOrRoot(path), referencedDimKey, reference, strings.Join(sortedDimKeys(i.names), ", "))
+	}
+	if !slices.Contains(i.references[dimKey], referencedDimKey) {
+		i.references[dimKey] = append(i.references[dimKey], referencedDimKey)
+	}
+
+	// interpolationExpr matches only <dim>.name and <dim>.data...
+	if parts[1] == "name" {
+		return i.names[referencedDimKey], nil
+	}
+
+	if slices.Contains(i.resolving, reference) {
+		return nil, fmt.Errorf("%s: reference cycle %s -> %s", pathOrRoot(path), strings.Join(i.resolving, " -> "), reference)
+	}
+
+	var referencedValue interface{} = i.data[referencedDimKey]
+	for _, key := range parts[2:] {
+		switch current := referencedValue.(type) {
+		case map[string]interface{}:
+			child, ok := current[key]
+			if !ok {
+				return nil, fmt.Errorf("%s: key %q of ${%s} not found in %s data", pathOrRoot(path), key, reference, referencedDimKey)
+			}
+			referencedValue = child
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(current) {
+				return nil, fmt.Errorf("%s: index %q of ${%s} not found in %s data", pathOrRoot(path), key, reference, referencedDimKey)
+			}
+			referencedValue = current[index]
+		default:
+			return nil, fmt.Errorf("%s: key %q of ${%s} not found in %s data", pathOrRoot(path), key, reference, referencedDimKey)
+		}
+	}
+
+	// referenced value could contain references too
+	i.resolving = append(i.resolving, reference)
+	defer func() { i.resolving = i.resolving[:len(i.resolving)-1] }()
+	return i.interpolate(referencedDimKey, referencedValue, path)
+}
+
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+func sortedDimKeys(dimensions map[string]string) []string {
+	dimKeys := make([]string, 0, len(dimensions))
+	for dimKey := range dimensions {
+		dimKeys = append(dimKeys, dimKey)
+	}
+	sort.Strings(dimKeys)
+	return dimKeys
+}
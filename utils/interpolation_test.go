@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInterpolateDimensions(t *testing.T) {
+	names := map[string]string{"account": "dev", "datacenter": "eu1"}
+	tests := []struct {
+		name           string
+		data           string
+		want           string
+		wantReferences map[string][]string
+		wantErr        string
+	}{
+		{
+			name: "name and data references embedded into string",
+			data: `{"account": {"cidr": "10.0.0.0/16", "size": 3}, "datacenter": {"label": "${account.name}-${datacenter.name}", "cidr": "${ account.data.cidr }", "note": "size ${account.data.size}"}}`,
+			want: `{"account": {"cidr": "10.0.0.0/16", "size": 3}, "datacenter": {"label": "dev-eu1", "cidr": "10.0.0.0/16", "note": "size 3"}}`,
+			wantReferences: map[string][]string{
+				"datacenter": {"account", "datacenter"},
+			},
+		},
+		{
+			name: "whole string reference keeps the type",
+			data: `{"account": {"zones": ["a", "b"], "size": 3, "public": true, "net": {"cidr": "10.0.0.0/16"}}, "datacenter": {"zones": "${account.data.zones}", "size": "${account.data.size}", "public": "${account.data.public}", "net": "${account.data.net}", "zone": "${account.data.zones.1}"}}`,
+			want: `{"account": {"zones": ["a", "b"], "size": 3, "public": true, "net": {"cidr": "10.0.0.0/16"}}, "datacenter": {"zones": ["a", "b"], "size": 3, "public": true, "net": {"cidr": "10.0.0.0/16"}, "zone": "b"}}`,
+		},
+		{
+			name: "nested references",
+			data: `{"account": {"prefix": "${datacenter.name}"}, "datacenter": {"label": "x-${account.data.prefix}"}}`,
+			want: `{"account": {"prefix": "eu1"}, "datacenter": {"label": "x-eu1"}}`,
+		},
+		{
+			name: "escaping and non-reference expressions are literal",
+			data: `{"account": {"escaped": "$${account.name}", "policy": "arn:aws:s3:::bucket/${aws:username}", "template": "Hello ${name}!", "each": "${each.value}", "empty": "${}"}}`,
+			want: `{"account": {"escaped": "${account.name}", "policy": "arn:aws:s3:::bucket/${aws:username}", "template": "Hello ${name}!", "each": "${each.value}", "empty": "${}"}}`,
+		},
+		{
+			name:    "unknown dimension",
+			data:    `{"account": {"x": "${region.name}"}}`,
+			wantErr: `/x: unknown dimension "region" in ${region.name}`,
+		},
+		{
+			name:    "missing key",
+			data:    `{"account": {"x": "${datacenter.data.missing}"}, "datacenter": {}}`,
+			wantErr: `/x: key "missing" of ${datacenter.data.missing} not found in datacenter data`,
+		},
+		{
+			name:    "index out of range",
+			data:    `{"account": {"x": "${datacenter.data.zones.2}"}, "datacenter": {"zones": ["a"]}}`,
+			wantErr: `/x: index "2" of ${datacenter.data.zones.2} not found in datacenter data`,
+		},
+		{
+			name:    "object can not be embedded",
+			data:    `{"account": {"x": "cidr ${datacenter.data.net}"}, "datacenter": {"net": {}}}`,
+			wantErr: "/x: ${datacenter.data.net} is an object or list and can not be embedded into string",
+		},
+		{
+			name:    "cycle",
+			data:    `{"account": {"a": "${datacenter.data.b}"}, "datacenter": {"b": "${account.data.a}"}}`,
+			wantErr: "reference cycle",
+		},
+		{
+			name:    "self cycle",
+			data:    `{"account": {"a": "${account.data.a}"}}`,
+			wantErr: "reference cycle",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data map[string]map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.data), &data); err != nil {
+				t.Fatal(err)
+			}
+			s := &State{ParsedDimensions: names}
+			references, err := s.interpolateDimensions(data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var want map[string]map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(data, want) {
+				t.Errorf("data = %s, want %s", jsonString(data), jsonString(want))
+			}
+			if tt.wantReferences != nil && !reflect.DeepEqual(references, tt.wantReferences) {
+				t.Errorf("references = %v, want %v", references, tt.wantReferences)
+			}
+		})
+	}
+}
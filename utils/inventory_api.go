@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	RegisterInventoryProvider("api", newApiInventoryProvider)
+}
+
+// apiInventoryProvider reads dimensions from IaCConsole API (CMDB)
+type apiInventoryProvider struct {
+	client           *apiClient
+	orgName          string
+	workspace        string
+	fallbackToMaster bool
+}
+
+func newApiInventoryProvider(s *State) (InventoryProvider, error) {
+	if s.ApiEndpoint == nil {
+		return nil, fmt.Errorf("IACCONSOLE_API_URL is not set for org %s", s.OrgName)
+	}
+	client, err := s.newApiClient()
+	if err != nil {
+		return nil, err
+	}
+	return &apiInventoryProvider{client: client, orgName: s.OrgName, workspace: s.Workspace, fallbackToMaster: true}, nil
+}
+
+func (p *apiInventoryProvider) GetDimension(dimensionKey string, dimensionValue string) (map[string]interface{}, error) {
+	dimension, err := p.getDimensionWithWorkspace(dimensionKey, dimensionValue)
+	return dimension.DimData, err
+}
+
+// getDimensionWithWorkspace returns dimension with the workspace it was found in, master if fallback happened
+func (p *apiInventoryProvider) getDimensionWithWorkspace(dimensionKey string, dimensionValue string) (DimensionInIaCConsoleDB, error) {
+	iacConsoleDBResponse, err := p.request(dimensionKey+"/"+dimensionValue, true)
+	if err != nil {
+		return DimensionInIaCConsoleDB{}, err
+	}
+	if len(iacConsoleDBResponse.Dimensions) != 1 {
+		return DimensionInIaCConsoleDB{}, fmt.Errorf("should be only one dimension in response")
+	}
+	return iacConsoleDBResponse.Dimensions[0], nil
+}
+
+func (p *apiInventoryProvider) ListDimension(dimensionKey string) ([]string, error) {
+	iacConsoleDBResponse, err := p.request(dimensionKey, false)
+	if err != nil {
+		return nil, err
+	}
+
+	dimensionValues := make([]string, 0, len(iacConsoleDBResponse.Dimensions))
+	for _, dimension := range iacConsoleDBResponse.Dimensions {
+		dimensionValues = append(dimensionValues, dimension.DimValue)
+	}
+	sort.Strings(dimensionValues)
+	return dimensionValues, nil
+}
+
+// ListDimensions returns dimensions of the org found in the list of all its values
+func (p *apiInventoryProvider) ListDimensions() ([]string, error) {
+	iacConsoleDBResponse, err := p.request("", false)
+	if err != nil {
+		return nil, err
+	}
+
+	var dimensionKeys []string
+	for _, dimension := range iacConsoleDBResponse.Dimensions {
+		if dimension.DimKey != "" && !slices.Contains(dimensionKeys, dimension.DimKey) {
+			dimensionKeys = append(dimensionKeys, dimension.DimKey)
+		}
+	}
+	sort.Strings(dimensionKeys)
+	return dimensionKeys, nil
+}
+
+func (p *apiInventoryProvider) DimensionExists(dimensionKey string, dimensionValue string) (bool, error) {
+	_, err := p.request(dimensionKey+"/"+dimensionValue, true)
+	if err == nil {
+		return true, nil
+	}
+	if isDimensionNotFound(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+// request gets /v1/dimension/<org>/<dimensionPath> in the workspace with fallback to master
+func (p *apiInventoryProvider) request(dimensionPath string, needDimData bool) (IaCConsoleDBResponse, error) {
+	var iacConsoleDBResponse IaCConsoleDBResponse
+
+	query := url.Values{"workspace": {p.workspace}, "fallbacktomaster": {strconv.FormatBool(p.fallbackToMaster)}}
+	if !needDimData {
+		query.Set("needdimdata", "false")
+	}
+	response, err := p.client.get(p.dimensionPath(dimensionPath), query)
+	if err != nil {
+		return iacConsoleDBResponse, err
+	}
+
+	if response.StatusCode == 404 {
+		return iacConsoleDBResponse, fmt.Errorf("dimension %s/%s: %w", p.orgName, dimensionPath, ErrDimensionNotFound)
+	}
+	if response.StatusCode != 200 {
+		return iacConsoleDBResponse, fmt.Errorf("request %s/%s?workspace=%s failed with response: %v", p.orgName, dimensionPath, p.workspace, response.StatusCode)
+	}
+
+	dimensionJsonBytes := response.Body
+	if err := json.Unmarshal(dimensionJsonBytes, &iacConsoleDBResponse); err != nil {
+		return iacConsoleDBResponse, fmt.Errorf("error during unmarshal json response: %v", err)
+	}
+	if iacConsoleDBResponse.Error != "" {
+		log.Println(iacConsoleDBResponse.Error)
+	}
+	return iacConsoleDBResponse, nil
+}
+
+// PutDimension uploads dimension data to the workspace, like examples/inventory-to-iacconsole-db.sh did
+func (p *apiInventoryProvider) PutDimension(dimensionKey string, dimensionValue string, dimensionJsonMap map[string]interface{}) error {
+	dimensionJsonBytes, err := json.Marshal(dimensionJsonMap)
+	if err != nil {
+		return fmt.Errorf("failed to marshal json: %v", err)
+	}
+	query := url.Values{"workspace": {p.workspace}, "source": {"inventory"}, "readonly": {"true"}}
+	return p.send(http.MethodPost, dimensionKey+"/"+dimensionValue, query, dimensionJsonBytes)
+}
+
+// DeleteDimension deletes dimension value from the workspace
+func (p *apiInventoryProvider) DeleteDimension(dimensionKey string, dimensionValue string) error {
+	return p.send(http.MethodDelete, dimensionKey+"/"+dimensionValue, url.Values{"workspace": {p.workspace}}, nil)
+}
+
+func (p *apiInventoryProvider) send(method string, dimensionPath string, query url.Values, body []byte) error {
+	response, err := p.client.send(method, p.dimensionPath(dimensionPath), query, body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("%s %s/%s?workspace=%s failed with response: %v %s", method, p.orgName, dimensionPath, p.workspace, response.StatusCode, bytes.TrimSpace(response.Body[:min(len(response.Body), 1024)]))
+	}
+	return nil
+}
+
+func (p *apiInventoryProvider) dimensionPath(dimensionPath string) string {
+	return "/v1/dimension/" + strings.TrimSuffix(p.orgName+"/"+dimensionPath, "/")
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+)
+
+// extendsKey is the reserved key in dimension data with the value (or dimension/value) its data is based on
+const extendsKey = "$extends"
+
+// resolveExtends deep-merges dimension data over the chain of its $extends bases.
+// Bases could be another value of the same dimension (staging-base) or value of any dimension, like shared base file (bases/datacenter)
+func (s *State) resolveExtends(provider InventoryProvider, dimensionKey string, dimensionValue string, dimensionJsonMap map[string]interface{}) (map[string]interface{}, error) {
+	chain := []string{dimensionKey + "/" + dimensionValue}
+	mergeChain := []map[string]interface{}{dimensionJsonMap}
+
+	for {
+		extends, ok := mergeChain[len(mergeChain)-1][extendsKey]
+		if !ok {
+			break
+		}
+		delete(mergeChain[len(mergeChain)-1], extendsKey)
+
+		extendsString, ok := extends.(string)
+		if !ok || extendsString == "" {
+			return nil, fmt.Errorf("%s of %s must be a dimension value name", extendsKey, chain[len(chain)-1])
+		}
+		baseKey, baseValue, found := strings.Cut(extendsString, "/")
+		if !found {
+			baseKey, baseValue = strings.Split(chain[len(chain)-1], "/")[0], extendsString
+		}
+		if slices.Contains(chain, baseKey+"/"+baseValue) {
+			return nil, fmt.Errorf("%s cycle: %s -> %s", extendsKey, strings.Join(chain, " -> "), baseKey+"/"+baseValue)
+		}
+		chain = append(chain, baseKey+"/"+baseValue)
+
+		baseJsonMap, err := provider.GetDimension(baseKey, baseValue)
+		if err != nil {
+			return nil, fmt.Errorf("%s chain %s: %v", extendsKey, strings.Join(chain, " -> "), err)
+		}
+		mergeChain = append(mergeChain, baseJsonMap)
+	}
+
+	if len(chain) == 1 {
+		return dimensionJsonMap, nil
+	}
+	if s.Verbose {
+		log.Printf("dimension %s/%s extends chain: %s", s.OrgName, chain[0], strings.Join(chain, " -> "))
+	}
+
+	mergedJsonMap := mergeChain[len(mergeChain)-1]
+	for i := len(mergeChain) - 2; i >= 0; i-- {
+		mergedJsonMap = mergeDimData(mergedJsonMap, mergeChain[i])
+	}
+	return mergedJsonMap, nil
+}
+
+// mergeDimData returns deep merge of override over base, nested objects are merged and lists are replaced.
+// $sensitive lists are joined, so keys marked sensitive in base stay sensitive
+func mergeDimData(base map[string]interface{}, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(override))
+	for key, value := range base {
+		merged[key] = value
+	}
+
+	for key, overrideValue := range override {
+		baseValue, ok := merged[key]
+		if !ok {
+			merged[key] = overrideValue
+			continue
+		}
+
+		baseMap, baseIsMap := baseValue.(map[string]interface{})
+		overrideMap, overrideIsMap := overrideValue.(map[string]interface{})
+		baseList, baseIsList := baseValue.([]interface{})
+		overrideList, overrideIsList := overrideValue.([]interface{})
+		switch {
+		case baseIsMap && overrideIsMap:
+			merged[key] = mergeDimData(baseMap, overrideMap)
+		case key == sensitiveKeysKey && baseIsList && overrideIsList:
+			merged[key] = append(slices.Clone(baseList), overrideList...)
+		default:
+			merged[key] = overrideValue
+		}
+	}
+	return merged
+}
@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// fakeInventoryProvider returns data of dimension values from JSON documents keyed by dimension/value
+type fakeInventoryProvider map[string]string
+
+func (p fakeInventoryProvider) GetDimension(dimensionKey string, dimensionValue string) (map[string]interface{}, error) {
+	document, ok := p[dimensionKey+"/"+dimensionValue]
+	if !ok {
+		return nil, fmt.Errorf("%s/%s: %w", dimensionKey, dimensionValue, ErrDimensionNotFound)
+	}
+	var data map[string]interface{}
+	err := json.Unmarshal([]byte(document), &data)
+	return data, err
+}
+
+func (p fakeInventoryProvider) ListDimension(dimensionKey string) ([]string, error) {
+	var values []string
+	for key := range p {
+		if dimKey, dimValue, _ := strings.Cut(key, "/"); dimKey == dimensionKey {
+			values = append(values, dimValue)
+		}
+	}
+	sort.Strings(values)
+	return values, nil
+}
+
+func (p fakeInventoryProvider) DimensionExists(dimensionKey string, dimensionValue string) (bool, error) {
+	_, ok := p[dimensionKey+"/"+dimensionValue]
+	return ok, nil
+}
+
+func TestResolveExtends(t *testing.T) {
+	provider := fakeInventoryProvider{
+		"account/base":        `{"region": "eu", "tags": {"team": "infra", "env": "none"}, "azs": ["a", "b"], "$sensitive": ["token"]}`,
+		"account/staging":     `{"$extends": "base", "tags": {"env": "staging"}, "azs": ["c"], "$sensitive": ["password"]}`,
+		"account/dev":         `{"$extends": "staging", "name": "dev"}`,
+		"account/shared":      `{"$extends": "bases/common", "name": "shared"}`,
+		"bases/common":        `{"owner": "platform"}`,
+		"account/loop-a":      `{"$extends": "loop-b"}`,
+		"account/loop-b":      `{"$extends": "loop-a"}`,
+		"account/missing":     `{"$extends": "nope"}`,
+		"account/not-string":  `{"$extends": ["base"]}`,
+		"account/self":        `{"$extends": "self"}`,
+		"account/no-extends":  `{"name": "plain"}`,
+		"account/empty-value": `{"$extends": ""}`,
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "chain is merged, objects deep and lists replaced, $sensitive joined",
+			value: "dev",
+			want:  `{"name": "dev", "region": "eu", "tags": {"team": "infra", "env": "staging"}, "azs": ["c"], "$sensitive": ["token", "password"]}`,
+		},
+		{
+			name:  "base of another dimension",
+			value: "shared",
+			want:  `{"name": "shared", "owner": "platform"}`,
+		},
+		{
+			name:  "without $extends",
+			value: "no-extends",
+			want:  `{"name": "plain"}`,
+		},
+		{
+			name:    "cycle",
+			value:   "loop-a",
+			wantErr: "$extends cycle: account/loop-a -> account/loop-b -> account/loop-a",
+		},
+		{
+			name:    "extends itself",
+			value:   "self",
+			wantErr: "$extends cycle: account/self -> account/self",
+		},
+		{
+			name:    "missing base",
+			value:   "missing",
+			wantErr: "$extends chain account/missing -> account/nope",
+		},
+		{
+			name:    "not a string",
+			value:   "not-string",
+			wantErr: "$extends of account/not-string must be a dimension value name",
+		},
+		{
+			name:    "empty string",
+			value:   "empty-value",
+			wantErr: "$extends of account/empty-value must be a dimension value name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &State{OrgName: "org", inventoryProvider: provider}
+			got, err := s.GetDimData("account", tt.value, false)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("data = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestMergeDimData(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		override string
+		want     string
+	}{
+		{
+			name:     "nested objects are merged",
+			base:     `{"a": {"b": 1, "c": {"d": 2}}, "e": 3}`,
+			override: `{"a": {"c": {"f": 4}}}`,
+			want:     `{"a": {"b": 1, "c": {"d": 2, "f": 4}}, "e": 3}`,
+		},
+		{
+			name:     "lists are replaced",
+			base:     `{"azs": ["a", "b"]}`,
+			override: `{"azs": []}`,
+			want:     `{"azs": []}`,
+		},
+		{
+			name:     "object replaced by scalar and null",
+			base:     `{"a": {"b": 1}, "c": "d"}`,
+			override: `{"a": "flat", "c": null}`,
+			want:     `{"a": "flat", "c": null}`,
+		},
+		{
+			name:     "$sensitive lists are joined",
+			base:     `{"$sensitive": ["token"]}`,
+			override: `{"$sensitive": ["password"]}`,
+			want:     `{"$sensitive": ["token", "password"]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var base, override, want map[string]interface{}
+			for _, document := range []struct {
+				json   string
+				target *map[string]interface{}
+			}{{tt.base, &base}, {tt.override, &override}, {tt.want, &want}} {
+				if err := json.Unmarshal([]byte(document.json), document.target); err != nil {
+					t.Fatal(err)
+				}
+			}
+			baseBefore := fmt.Sprint(base)
+
+			got := mergeDimData(base, override)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("merged = %v, want %v", got, want)
+			}
+			if fmt.Sprint(base) != baseBefore {
+				t.Errorf("base is modified: %v", base)
+			}
+		})
+	}
+}
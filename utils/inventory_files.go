@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+)
+
+func init() {
+	RegisterInventoryProvider("files", newFilesInventoryProvider)
+}
+
+// filesInventoryProvider reads dimensions from <inventory_path>/<org>/<dimension>/<value>.<json|yaml|yml|toml|hcl>
+// in the working tree, or in git refs of the workspace with fallback to master/main if inventory_git_workspaces is enabled
+type filesInventoryProvider struct {
+	inventoryPath string
+	tree          inventoryTree
+	// refs to look for the file in, in the order of fallback. Only "" for the working tree
+	refs []string
+}
+
+func newFilesInventoryProvider(s *State) (InventoryProvider, error) {
+	if s.InventoryPath == "" {
+		return nil, fmt.Errorf("inventory_path is not configured for org %s", s.OrgName)
+	}
+	if !s.GetBoolFromViperByOrgOrDefault("inventory_git_workspaces") {
+		return &filesInventoryProvider{inventoryPath: s.InventoryPath, tree: osInventoryTree{root: s.InventoryPath}, refs: []string{""}}, nil
+	}
+
+	refs, err := resolveGitWorkspaceRefs(s.InventoryPath, s.Workspace)
+	if err != nil {
+		return nil, err
+	}
+	return &filesInventoryProvider{inventoryPath: s.InventoryPath, tree: gitInventoryTree{dir: s.InventoryPath}, refs: refs}, nil
+}
+
+func (p *filesInventoryProvider) GetDimension(dimensionKey string, dimensionValue string) (map[string]interface{}, error) {
+	dimensionPath, format, ref, err := p.findDimensionFile(dimensionKey, dimensionValue)
+	if err != nil {
+		return nil, err
+	}
+
+	dimensionBytes, err := p.tree.readFile(ref, dimensionPath)
+	if err != nil {
+		return nil, err
+	}
+	if ref != "" {
+		log.Println("inventory files: " + dimensionKey + "/" + dimensionValue + " read from git ref " + ref)
+	}
+	dimensionJsonMap, err := format.decode(dimensionBytes, p.displayPath(ref, dimensionPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", p.displayPath(ref, dimensionPath), err)
+	}
+	return dimensionJsonMap, nil
+}
+
+func (p *filesInventoryProvider) ListDimension(dimensionKey string) ([]string, error) {
+	return p.listDimensionFiles(dimensionKey, false)
+}
+
+// listDimensionFiles returns sorted values of the dimension, withDimFiles adds dim_ files (like dim_defaults) which are not dimension values
+func (p *filesInventoryProvider) listDimensionFiles(dimensionKey string, withDimFiles bool) ([]string, error) {
+	var dimensionValues []string
+	for _, ref := range p.refs {
+		names, err := p.tree.listDir(ref, dimensionKey, false)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, name := range names {
+			if !withDimFiles && strings.HasPrefix(name, "dim_") {
+				continue
+			}
+			for _, format := range inventoryFileFormats {
+				if strings.HasSuffix(name, format.extension) {
+					dimensionValue := strings.TrimSuffix(name, format.extension)
+					if !slices.Contains(dimensionValues, dimensionValue) {
+						dimensionValues = append(dimensionValues, dimensionValue)
+					}
+				}
+			}
+		}
+	}
+	sort.Strings(dimensionValues)
+	return dimensionValues, nil
+}
+
+func (p *filesInventoryProvider) ListDimensions() ([]string, error) {
+	var dimensionKeys []string
+	for _, ref := range p.refs {
+		names, err := p.tree.listDir(ref, "", true)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			if !strings.HasPrefix(name, ".") && !slices.Contains(dimensionKeys, name) {
+				dimensionKeys = append(dimensionKeys, name)
+			}
+		}
+	}
+	sort.Strings(dimensionKeys)
+	return dimensionKeys, nil
+}
+
+func (p *filesInventoryProvider) DimensionExists(dimensionKey string, dimensionValue string) (bool, error) {
+	_, _, _, err := p.findDimensionFile(dimensionKey, dimensionValue)
+	if isDimensionNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// findDimensionFile returns path (relative to inventory path), format and git ref of the dimension value file.
+// The first ref with the value is used, only one file of all the supported formats is allowed for the value
+func (p *filesInventoryProvider) findDimensionFile(dimensionKey string, dimensionValue string) (string, inventoryFileFormat, string, error) {
+	var foundFormat inventoryFileFormat
+
+	for _, ref := range p.refs {
+		var foundPaths []string
+		for _, format := range inventoryFileFormats {
+			dimensionPath := dimensionKey + "/" + dimensionValue + format.extension
+			found, err := p.tree.exists(ref, dimensionPath)
+			if err != nil {
+				return "", foundFormat, ref, err
+			}
+			if found {
+				foundPaths = append(foundPaths, dimensionPath)
+				foundFormat = format
+			}
+		}
+
+		switch len(foundPaths) {
+		case 0:
+			continue
+		case 1:
+			return foundPaths[0], foundFormat, ref, nil
+		}
+		for i := range foundPaths {
+			foundPaths[i] = p.displayPath(ref, foundPaths[i])
+		}
+		return "", foundFormat, ref, fmt.Errorf("dimension %s/%s is defined in several files, keep only one of them: %s", dimensionKey, dimensionValue, strings.Join(foundPaths, ", "))
+	}
+	return "", foundFormat, "", fmt.Errorf("inventory files: %s/%s: %w", dimensionKey, dimensionValue, ErrDimensionNotFound)
+}
+
+// displayPath returns path of the file for logs and errors, like /inventory/org/dim/value.json or master:/inventory/org/dim/value.json
+func (p *filesInventoryProvider) displayPath(ref string, relativePath string) string {
+	if ref == "" {
+		return p.inventoryPath + "/" + relativePath
+	}
+	return ref + ":" + p.inventoryPath + "/" + relativePath
+}
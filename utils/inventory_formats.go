@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/pelletier/go-toml/v2"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+	"go.yaml.in/yaml/v3"
+)
+
+// inventoryFileFormat decodes inventory file of one format to dimension data
+type inventoryFileFormat struct {
+	extension string
+	decode    func(fileBytes []byte, filePath string) (map[string]interface{}, error)
+}
+
+// inventoryFileFormats are supported formats of inventory files, in the order of lookup
+var inventoryFileFormats = []inventoryFileFormat{
+	{extension: ".json", decode: decodeJsonInventory},
+	{extension: ".yaml", decode: decodeYamlInventory},
+	{extension: ".yml", decode: decodeYamlInventory},
+	{extension: ".toml", decode: decodeTomlInventory},
+	{extension: ".hcl", decode: decodeHclInventory},
+}
+
+func decodeJsonInventory(fileBytes []byte, filePath string) (map[string]interface{}, error) {
+	var dimensionJsonMap map[string]interface{}
+	if err := json.Unmarshal(fileBytes, &dimensionJsonMap); err != nil {
+		return nil, err
+	}
+	return dimensionJsonMap, nil
+}
+
+func decodeYamlInventory(fileBytes []byte, filePath string) (map[string]interface{}, error) {
+	var dimensionYamlNode yaml.Node
+	if err := yaml.Unmarshal(fileBytes, &dimensionYamlNode); err != nil {
+		return nil, err
+	}
+	keepYamlTimestampsAsStrings(&dimensionYamlNode)
+
+	var dimensionYamlMap map[string]interface{}
+	if err := dimensionYamlNode.Decode(&dimensionYamlMap); err != nil {
+		return nil, err
+	}
+	return normalizeInventoryData(dimensionYamlMap)
+}
+
+// keepYamlTimestampsAsStrings keeps dates like 2024-01-01 as written instead of converting them to timestamps
+func keepYamlTimestampsAsStrings(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!timestamp" {
+		node.Tag = "!!str"
+	}
+	for _, child := range node.Content {
+		keepYamlTimestampsAsStrings(child)
+	}
+}
+
+func decodeTomlInventory(fileBytes []byte, filePath string) (map[string]interface{}, error) {
+	var dimensionTomlMap map[string]interface{}
+	if err := toml.Unmarshal(fileBytes, &dimensionTomlMap); err != nil {
+		return nil, err
+	}
+	return normalizeInventoryData(dimensionTomlMap)
+}
+
+// decodeHclInventory reads top level attributes of HCL file, like `region = "us-east-1"`.
+// Functions and variables are not available in the expressions
+func decodeHclInventory(fileBytes []byte, filePath string) (map[string]interface{}, error) {
+	hclFile, diags := hclsyntax.ParseConfig(fileBytes, filePath, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	attributes, diags := hclFile.Body.JustAttributes()
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	dimensionHclMap := make(map[string]interface{}, len(attributes))
+	for name, attribute := range attributes {
+		value, diags := attribute.Expr.Value(nil)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		valueJsonBytes, err := ctyjson.Marshal(value, value.Type())
+		if err != nil {
+			return nil, fmt.Errorf("attribute %s: %v", name, err)
+		}
+		dimensionHclMap[name] = json.RawMessage(valueJsonBytes)
+	}
+	return normalizeInventoryData(dimensionHclMap)
+}
+
+// normalizeInventoryData converts decoded data to the same types as decoded from JSON
+// (float64 numbers, string dates, map[string]interface{} objects)
+func normalizeInventoryData(dimensionData map[string]interface{}) (map[string]interface{}, error) {
+	dimensionJsonBytes, err := json.Marshal(dimensionData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert to json: %v", err)
+	}
+	return decodeJsonInventory(dimensionJsonBytes, "")
+}
@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrDimensionNotFound is returned (wrapped) by inventory providers when requested dimension value does not exist
+var ErrDimensionNotFound = errors.New("dimension not found")
+
+// InventoryProvider is the source of dimensions data for the org
+type InventoryProvider interface {
+	// GetDimension returns data of the dimension value, ErrDimensionNotFound if there is no such value
+	GetDimension(dimensionKey string, dimensionValue string) (map[string]interface{}, error)
+	// ListDimension returns sorted names of all the values of the dimension
+	ListDimension(dimensionKey string) ([]string, error)
+	// DimensionExists checks if the dimension value exists
+	DimensionExists(dimensionKey string, dimensionValue string) (bool, error)
+}
+
+// InventoryDimensionsLister is implemented by providers able to list all the dimensions of the org
+type InventoryDimensionsLister interface {
+	ListDimensions() ([]string, error)
+}
+
+// InventoryWriter is implemented by providers which could be updated by inventory push
+type InventoryWriter interface {
+	PutDimension(dimensionKey string, dimensionValue string, dimensionJsonMap map[string]interface{}) error
+	DeleteDimension(dimensionKey string, dimensionValue string) error
+}
+
+// InventoryProviderFactory creates provider for the org, workspace and paths of the State
+type InventoryProviderFactory func(s *State) (InventoryProvider, error)
+
+var (
+	inventoryProvidersMu sync.RWMutex
+	inventoryProviders   = map[string]InventoryProviderFactory{}
+)
+
+// RegisterInventoryProvider makes provider available by name in inventory_provider config key.
+// Usually called from init() of the package implementing the provider
+func RegisterInventoryProvider(name string, factory InventoryProviderFactory) {
+	inventoryProvidersMu.Lock()
+	defer inventoryProvidersMu.Unlock()
+	if _, ok := inventoryProviders[name]; ok {
+		panic("inventory provider " + name + " is already registered")
+	}
+	inventoryProviders[name] = factory
+}
+
+// InventoryProviders returns sorted names of registered providers
+func InventoryProviders() []string {
+	inventoryProvidersMu.RLock()
+	defer inventoryProvidersMu.RUnlock()
+	names := make([]string, 0, len(inventoryProviders))
+	for name := range inventoryProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// InventoryProviderName returns InventorySource if set, provider configured in inventory_provider for the org,
+// api if IACCONSOLE_API_URL is set and files otherwise
+func (s *State) InventoryProviderName() string {
+	if s.InventorySource != "" {
+		return s.InventorySource
+	}
+	if providerName := s.GetStringFromViperByOrgOrDefault("inventory_provider"); providerName != "" {
+		return providerName
+	}
+	if s.ApiEndpoint != nil {
+		return "api"
+	}
+	return "files"
+}
+
+// inventorySource returns description of the inventory of the org for variables descriptions, like inventory files /inventory/org
+func (s *State) inventorySource() string {
+	switch providerName := s.InventoryProviderName(); providerName {
+	case "files":
+		return "inventory files " + s.InventoryPath
+	case "api":
+		if s.ApiEndpoint == nil {
+			return "IaCConsole API"
+		}
+		return "IaCConsole API " + s.ApiEndpoint.BaseUrl + " workspace " + s.Workspace
+	default:
+		return "inventory provider " + providerName
+	}
+}
+
+// InventoryProvider returns provider of the org, it is created on the first call
+func (s *State) InventoryProvider() (InventoryProvider, error) {
+	if s.inventoryProvider != nil {
+		return s.inventoryProvider, nil
+	}
+
+	providerName := s.InventoryProviderName()
+	inventoryProvidersMu.RLock()
+	factory, ok := inventoryProviders[providerName]
+	inventoryProvidersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown inventory_provider %q for org %s, available: %v", providerName, s.OrgName, InventoryProviders())
+	}
+
+	provider, err := factory(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create inventory provider %s: %v", providerName, err)
+	}
+	s.inventoryProvider = provider
+	return provider, nil
+}
@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// InventoryWorkspacesFileName is written by inventory pull next to the dimensions with the workspace of every pulled value
+const InventoryWorkspacesFileName = ".iacconsole_workspaces.json"
+
+// PullInventory writes all the values of the dimensions (all the dimensions of the org if empty) from the workspace in IaCConsole API
+// to <outDir>/<org>/<dimension>/<value>.json, the layout of Inventory Files. With prune JSON files of values missing in the API
+// are removed (dim_ files are never removed). Returns the workspace every value came from, master if fallback happened
+func (s *State) PullInventory(outDir string, dimensionKeys []string, prune bool) (map[string]string, error) {
+	sourceProvider, err := newApiInventoryProvider(s)
+	if err != nil {
+		return nil, err
+	}
+	source := sourceProvider.(*apiInventoryProvider)
+
+	if len(dimensionKeys) == 0 {
+		if dimensionKeys, err = source.ListDimensions(); err != nil {
+			return nil, fmt.Errorf("failed to list dimensions of %s: %v", s.OrgName, err)
+		}
+	}
+
+	valueWorkspaces := make(map[string]string)
+	for _, dimensionKey := range dimensionKeys {
+		if err := validateInventoryPathElement(dimensionKey); err != nil {
+			return nil, fmt.Errorf("dimension of %s: %v", s.OrgName, err)
+		}
+		dimensionValues, err := source.ListDimension(dimensionKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s/%s: %v", s.OrgName, dimensionKey, err)
+		}
+		// values are validated before anything is written
+		for _, dimensionValue := range dimensionValues {
+			if err := validateInventoryPathElement(dimensionValue); err != nil {
+				return nil, fmt.Errorf("value of %s/%s: %v", s.OrgName, dimensionKey, err)
+			}
+		}
+
+		dimensionDir := filepath.Join(outDir, s.OrgName, dimensionKey)
+		if err := os.MkdirAll(dimensionDir, 0755); err != nil {
+			return nil, err
+		}
+		for _, dimensionValue := range dimensionValues {
+			dimension, err := source.getDimensionWithWorkspace(dimensionKey, dimensionValue)
+			if err != nil {
+				return nil, err
+			}
+			if err := writeInventoryJson(dimension.DimData, filepath.Join(dimensionDir, dimensionValue+".json")); err != nil {
+				return nil, err
+			}
+			valueWorkspaces[dimensionKey+"/"+dimensionValue] = dimension.WorkSpace
+		}
+
+		if prune {
+			if err := removeStaleInventoryJson(dimensionDir, dimensionValues); err != nil {
+				return nil, err
+			}
+		}
+		log.Printf("pulled %d values of %s/%s", len(dimensionValues), s.OrgName, dimensionKey)
+	}
+	return valueWorkspaces, nil
+}
+
+// writeInventoryJson writes pretty-printed JSON with sorted keys, so pulled files are stable in git
+func writeInventoryJson(document interface{}, jsonPath string) error {
+	documentBytes, err := json.MarshalIndent(document, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal json: %v", err)
+	}
+	if err := os.WriteFile(jsonPath, append(documentBytes, '\n'), 0644); err != nil {
+		return fmt.Errorf("error writing file: %v", err)
+	}
+	return nil
+}
+
+// WriteInventoryWorkspaces writes workspaces of the pulled values to InventoryWorkspacesFileName in <outDir>/<org>
+func (s *State) WriteInventoryWorkspaces(outDir string, valueWorkspaces map[string]string) error {
+	return writeInventoryJson(valueWorkspaces, filepath.Join(outDir, s.OrgName, InventoryWorkspacesFileName))
+}
+
+// validateInventoryPathElement rejects dimension keys and values from the API which can not be used as a file name,
+// so they never point outside of the dimension dir
+func validateInventoryPathElement(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`+"\x00") {
+		return fmt.Errorf("%q can not be used as file name", name)
+	}
+	return nil
+}
+
+// removeStaleInventoryJson removes JSON files of values not in dimensionValues, except dim_ files like dim_defaults or dim_schema
+func removeStaleInventoryJson(dimensionDir string, dimensionValues []string) error {
+	dirEntries, err := os.ReadDir(dimensionDir)
+	if err != nil {
+		return err
+	}
+	for _, dirEntry := range dirEntries {
+		dimensionValue, isJson := strings.CutSuffix(dirEntry.Name(), ".json")
+		if dirEntry.IsDir() || !isJson || strings.HasPrefix(dimensionValue, "dim_") || slices.Contains(dimensionValues, dimensionValue) {
+			continue
+		}
+		log.Println("removing value missing in IaCConsole API: " + filepath.Join(dimensionDir, dirEntry.Name()))
+		if err := os.Remove(filepath.Join(dimensionDir, dirEntry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestPullInventory(t *testing.T) {
+	tests := []struct {
+		name          string
+		values        map[string]map[string]map[string]interface{}
+		dimensionKeys []string
+		prune         bool
+		wantFiles     []string
+		wantErr       string
+	}{
+		{
+			name:      "stale files are kept without prune",
+			values:    map[string]map[string]map[string]interface{}{"account": {"dev": {"id": 1.0}}},
+			wantFiles: []string{"account/dev.json", "account/dim_defaults.json", "account/local.yaml", "account/old.json"},
+		},
+		{
+			name:      "prune removes only json of missing values",
+			values:    map[string]map[string]map[string]interface{}{"account": {"dev": {"id": 1.0}}},
+			prune:     true,
+			wantFiles: []string{"account/dev.json", "account/dim_defaults.json", "account/local.yaml"},
+		},
+		{
+			name:          "value with path separator",
+			values:        map[string]map[string]map[string]interface{}{"account": {"dev": {}, "../../evil": {}}},
+			dimensionKeys: []string{"account"},
+			wantErr:       `"../../evil" can not be used as file name`,
+		},
+		{
+			name:    "dimension from the API with path separator",
+			values:  map[string]map[string]map[string]interface{}{"a/b": {"dev": {}}},
+			wantErr: `"a/b" can not be used as file name`,
+		},
+		{
+			name:          "dimension ..",
+			values:        map[string]map[string]map[string]interface{}{},
+			dimensionKeys: []string{".."},
+			wantErr:       `".." can not be used as file name`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newFakeInventoryApiState(t, "pull-test", &fakeInventoryApi{values: tt.values})
+			outDir := writeInventoryFiles(t, map[string]string{
+				"pull-test/account/old.json":          `{}`,
+				"pull-test/account/dim_defaults.json": `{}`,
+				"pull-test/account/local.yaml":        `a: b`,
+			})
+
+			_, err := s.PullInventory(outDir, tt.dimensionKeys, tt.prune)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				if _, err := os.Stat(filepath.Join(outDir, "evil.json")); err == nil {
+					t.Errorf("file written outside of the dimension dir")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var files []string
+			filepath.WalkDir(filepath.Join(outDir, "pull-test"), func(path string, d os.DirEntry, err error) error {
+				if err == nil && !d.IsDir() {
+					relPath, _ := filepath.Rel(filepath.Join(outDir, "pull-test"), path)
+					files = append(files, filepath.ToSlash(relPath))
+				}
+				return err
+			})
+			sort.Strings(files)
+			if !reflect.DeepEqual(files, tt.wantFiles) {
+				t.Errorf("files = %v, want %v", files, tt.wantFiles)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"slices"
+)
+
+const (
+	InventoryChangeCreate = "create"
+	InventoryChangeUpdate = "update"
+	InventoryChangeDelete = "delete"
+)
+
+// InventoryChange is one change of inventory push
+type InventoryChange struct {
+	Dimension string `json:"dimension" yaml:"dimension"`
+	Value     string `json:"value" yaml:"value"`
+	Change    string `json:"change" yaml:"change"`
+	Error     string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// PushInventory compares Inventory Files of the org with the workspace in IaCConsole API and uploads new and changed values.
+// With prune values missing in the files are deleted from the workspace (only in dimensions existing in the files).
+// With dryRun changes are only returned. Failed changes have Error set, the rest are still applied
+func (s *State) PushInventory(dryRun bool, prune bool) ([]InventoryChange, error) {
+	sourceProvider, err := newFilesInventoryProvider(s)
+	if err != nil {
+		return nil, err
+	}
+	targetProvider, err := newApiInventoryProvider(s)
+	if err != nil {
+		return nil, err
+	}
+	source := sourceProvider.(*filesInventoryProvider)
+	target := targetProvider.(*apiInventoryProvider)
+	// values of the workspace itself are compared, not the ones from master
+	target.fallbackToMaster = false
+
+	dimensionKeys, err := source.ListDimensions()
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []InventoryChange{}
+	for _, dimensionKey := range dimensionKeys {
+		localValues, err := source.listDimensionFiles(dimensionKey, true)
+		if err != nil {
+			return nil, err
+		}
+		remoteValues, err := target.ListDimension(dimensionKey)
+		if err != nil && !isDimensionNotFound(err) {
+			return nil, fmt.Errorf("failed to list %s/%s: %v", s.OrgName, dimensionKey, err)
+		}
+
+		for _, dimensionValue := range localValues {
+			localData, err := source.GetDimension(dimensionKey, dimensionValue)
+			if err != nil {
+				return nil, err
+			}
+
+			change := InventoryChange{Dimension: dimensionKey, Value: dimensionValue, Change: InventoryChangeUpdate}
+			if !slices.Contains(remoteValues, dimensionValue) {
+				change.Change = InventoryChangeCreate
+			} else {
+				remoteData, err := target.GetDimension(dimensionKey, dimensionValue)
+				if err != nil && !isDimensionNotFound(err) {
+					return nil, err
+				}
+				if jsonString(remoteData) == jsonString(localData) {
+					if s.Verbose {
+						log.Printf("%s/%s/%s is up to date", s.OrgName, dimensionKey, dimensionValue)
+					}
+					continue
+				}
+			}
+
+			if !dryRun {
+				if err := target.PutDimension(dimensionKey, dimensionValue, localData); err != nil {
+					change.Error = err.Error()
+				}
+			}
+			changes = append(changes, change)
+		}
+
+		if !prune {
+			continue
+		}
+		for _, dimensionValue := range remoteValues {
+			if slices.Contains(localValues, dimensionValue) {
+				continue
+			}
+			change := InventoryChange{Dimension: dimensionKey, Value: dimensionValue, Change: InventoryChangeDelete}
+			if !dryRun {
+				if err := target.DeleteDimension(dimensionKey, dimensionValue); err != nil {
+					change.Error = err.Error()
+				}
+			}
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
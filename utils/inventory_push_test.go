@@ -0,0 +1,208 @@
+package utils
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// fakeInventoryApi is in-memory IaCConsole API with values of one org and workspace
+type fakeInventoryApi struct {
+	mu sync.Mutex
+	// values are data by dimension key and value
+	values map[string]map[string]map[string]interface{}
+	// failValues are rejected on upload and deletion
+	failValues []string
+	requests   []string
+}
+
+func (f *fakeInventoryApi) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// /v1/dimension/<org>[/<key>[/<value>]]
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/v1/dimension/"), "/", 3)
+	if r.Method != http.MethodGet {
+		f.requests = append(f.requests, r.Method+" "+strings.Join(parts[1:], "/"))
+	}
+	if len(parts) == 3 && slices.Contains(f.failValues, parts[2]) && r.Method != http.MethodGet {
+		http.Error(w, "rejected", http.StatusBadRequest)
+		return
+	}
+
+	response := IaCConsoleDBResponse{}
+	switch {
+	case r.Method == http.MethodGet && len(parts) == 1:
+		for dimensionKey, values := range f.values {
+			for dimensionValue := range values {
+				response.Dimensions = append(response.Dimensions, DimensionInIaCConsoleDB{DimKey: dimensionKey, DimValue: dimensionValue})
+			}
+		}
+	case r.Method == http.MethodGet && len(parts) == 2:
+		if len(f.values[parts[1]]) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+		for dimensionValue := range f.values[parts[1]] {
+			response.Dimensions = append(response.Dimensions, DimensionInIaCConsoleDB{DimKey: parts[1], DimValue: dimensionValue})
+		}
+	case r.Method == http.MethodGet && len(parts) == 3:
+		data, ok := f.values[parts[1]][parts[2]]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		response.Dimensions = append(response.Dimensions, DimensionInIaCConsoleDB{DimKey: parts[1], DimValue: parts[2], DimData: data})
+	case r.Method == http.MethodPost && len(parts) == 3:
+		var data map[string]interface{}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &data); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if f.values[parts[1]] == nil {
+			f.values[parts[1]] = map[string]map[string]interface{}{}
+		}
+		f.values[parts[1]][parts[2]] = data
+	case r.Method == http.MethodDelete && len(parts) == 3:
+		delete(f.values[parts[1]], parts[2])
+	default:
+		http.Error(w, "unexpected request", http.StatusMethodNotAllowed)
+		return
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// newFakeInventoryApiState returns State of the org with the fake API endpoint and cache in temp dir
+func newFakeInventoryApiState(t *testing.T, orgName string, api *fakeInventoryApi) *State {
+	t.Helper()
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+	endpoint, err := ParseApiEndpoint(server.URL, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	viper.Set(orgName+".api_cache_dir", t.TempDir())
+	viper.Set(orgName+".api_retries", "0")
+	return &State{OrgName: orgName, Workspace: "master", ApiEndpoint: endpoint}
+}
+
+func writeInventoryFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	inventoryPath := t.TempDir()
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(inventoryPath, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(inventoryPath, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return inventoryPath
+}
+
+func TestPushInventory(t *testing.T) {
+	files := map[string]string{
+		"account/dev.json":          `{"id": 1}`,
+		"account/prod.json":         `{"id": 2}`,
+		"account/stage.json":        `{"id": 3}`,
+		"account/dim_defaults.json": `{"region": "eu"}`,
+	}
+	remote := func() map[string]map[string]map[string]interface{} {
+		return map[string]map[string]map[string]interface{}{
+			"account": {
+				"dev":  {"id": 1.0},
+				"prod": {"id": 0.0},
+				"old":  {"id": 9.0},
+			},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		dryRun       bool
+		prune        bool
+		failValues   []string
+		wantChanges  []InventoryChange
+		wantRequests []string
+	}{
+		{
+			name:   "dry run",
+			dryRun: true,
+			prune:  true,
+			wantChanges: []InventoryChange{
+				{Dimension: "account", Value: "dim_defaults", Change: InventoryChangeCreate},
+				{Dimension: "account", Value: "prod", Change: InventoryChangeUpdate},
+				{Dimension: "account", Value: "stage", Change: InventoryChangeCreate},
+				{Dimension: "account", Value: "old", Change: InventoryChangeDelete},
+			},
+		},
+		{
+			name: "push without prune",
+			wantChanges: []InventoryChange{
+				{Dimension: "account", Value: "dim_defaults", Change: InventoryChangeCreate},
+				{Dimension: "account", Value: "prod", Change: InventoryChangeUpdate},
+				{Dimension: "account", Value: "stage", Change: InventoryChangeCreate},
+			},
+			wantRequests: []string{"POST account/dim_defaults", "POST account/prod", "POST account/stage"},
+		},
+		{
+			name:  "push with prune",
+			prune: true,
+			wantChanges: []InventoryChange{
+				{Dimension: "account", Value: "dim_defaults", Change: InventoryChangeCreate},
+				{Dimension: "account", Value: "prod", Change: InventoryChangeUpdate},
+				{Dimension: "account", Value: "stage", Change: InventoryChangeCreate},
+				{Dimension: "account", Value: "old", Change: InventoryChangeDelete},
+			},
+			wantRequests: []string{"POST account/dim_defaults", "POST account/prod", "POST account/stage", "DELETE account/old"},
+		},
+		{
+			name:       "partial failure",
+			prune:      true,
+			failValues: []string{"prod", "old"},
+			wantChanges: []InventoryChange{
+				{Dimension: "account", Value: "dim_defaults", Change: InventoryChangeCreate},
+				{Dimension: "account", Value: "prod", Change: InventoryChangeUpdate, Error: "failed"},
+				{Dimension: "account", Value: "stage", Change: InventoryChangeCreate},
+				{Dimension: "account", Value: "old", Change: InventoryChangeDelete, Error: "failed"},
+			},
+			wantRequests: []string{"POST account/dim_defaults", "POST account/prod", "POST account/stage", "DELETE account/old"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeInventoryApi{values: remote(), failValues: tt.failValues}
+			s := newFakeInventoryApiState(t, "push-test", api)
+			s.InventoryPath = writeInventoryFiles(t, files)
+
+			changes, err := s.PushInventory(tt.dryRun, tt.prune)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// only presence of the error is compared, the message contains URL of the test server
+			for i := range changes {
+				if changes[i].Error != "" {
+					changes[i].Error = "failed"
+				}
+			}
+			if !reflect.DeepEqual(changes, tt.wantChanges) {
+				t.Errorf("changes = %+v, want %+v", changes, tt.wantChanges)
+			}
+			if !reflect.DeepEqual(api.requests, tt.wantRequests) {
+				t.Errorf("requests = %v, want %v", api.requests, tt.wantRequests)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// inventoryTree reads files of the inventory by paths relative to the inventory path, ref is ignored by the working tree
+type inventoryTree interface {
+	exists(ref string, relativePath string) (bool, error)
+	readFile(ref string, relativePath string) ([]byte, error)
+	// listDir returns names of files (or dirs) in the dir, empty relativeDir is the inventory path itself
+	listDir(ref string, relativeDir string, dirs bool) ([]string, error)
+}
+
+// osInventoryTree reads files from the working tree
+type osInventoryTree struct {
+	root string
+}
+
+func (t osInventoryTree) exists(ref string, relativePath string) (bool, error) {
+	_, err := os.Stat(filepath.Join(t.root, relativePath))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (t osInventoryTree) readFile(ref string, relativePath string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(t.root, relativePath))
+}
+
+func (t osInventoryTree) listDir(ref string, relativeDir string, dirs bool) ([]string, error) {
+	dirEntries, err := os.ReadDir(filepath.Join(t.root, relativeDir))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() == dirs {
+			names = append(names, dirEntry.Name())
+		}
+	}
+	return names, nil
+}
+
+// gitInventoryTree reads files from git refs of the repository containing the inventory path without checkout
+type gitInventoryTree struct {
+	dir string
+}
+
+func (t gitInventoryTree) exists(ref string, relativePath string) (bool, error) {
+	return gitObjectExists(t.dir, ref+":./"+relativePath), nil
+}
+
+func (t gitInventoryTree) readFile(ref string, relativePath string) ([]byte, error) {
+	return runInDir(t.dir, "git", "show", ref+":./"+relativePath)
+}
+
+func (t gitInventoryTree) listDir(ref string, relativeDir string, dirs bool) ([]string, error) {
+	treeObject := ref + ":./" + relativeDir
+	if !gitObjectExists(t.dir, treeObject) {
+		// dimension could exist only in some of the refs
+		return nil, nil
+	}
+	lsTree, err := runInDir(t.dir, "git", "ls-tree", "--full-tree", treeObject)
+	if err != nil {
+		return nil, err
+	}
+
+	objectType := "blob"
+	if dirs {
+		objectType = "tree"
+	}
+	var names []string
+	for _, line := range strings.Split(strings.TrimSpace(string(lsTree)), "\n") {
+		// <mode> SP <type> SP <object> TAB <file>
+		entry, name, found := strings.Cut(line, "\t")
+		if found && strings.Fields(entry)[1] == objectType {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func gitObjectExists(dir string, object string) bool {
+	gitCmd := exec.Command("git", "cat-file", "-e", object)
+	gitCmd.Dir = dir
+	return gitCmd.Run() == nil
+}
+
+// resolveGitWorkspaceRefs returns existing refs for the workspace with fallback to master and main,
+// origin/<ref> is used if there is no local branch (like in CI clones)
+func resolveGitWorkspaceRefs(dir string, workspace string) ([]string, error) {
+	if _, err := runInDir(dir, "git", "rev-parse", "--git-dir"); err != nil {
+		return nil, fmt.Errorf("inventory_git_workspaces is enabled, but %s is not in git repository: %v", dir, err)
+	}
+
+	var refs []string
+	for _, ref := range []string{workspace, "master", "main"} {
+		for _, candidate := range []string{ref, "origin/" + ref} {
+			if ref != "" && !slices.Contains(refs, candidate) && gitObjectExists(dir, candidate+"^{commit}") {
+				refs = append(refs, candidate)
+				break
+			}
+		}
+	}
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("none of git refs %s, master, main found in %s", workspace, dir)
+	}
+	if refs[0] != workspace && refs[0] != "origin/"+workspace {
+		log.Printf("inventory files: git ref %s not found, falling back to %s", workspace, refs[0])
+	}
+	return refs, nil
+}
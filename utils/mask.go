@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// sensitiveKeysKey is the reserved key in dimension data with the list of sensitive keys of this data
+	sensitiveKeysKey = "$sensitive"
+	maskedValue      = "***"
+	// shorter values are not masked, otherwise every "1" or "true" in the output would be masked
+	minMaskedValueLength = 4
+)
+
+// collectSensitiveValues removes $sensitive list from dimension data and remembers values of the sensitive keys
+// (from $sensitive and sensitive_keys of the unit manifest) found at any depth of the data to mask them in the output.
+// source (like account/dev) names the data in warnings
+func (s *State) collectSensitiveValues(source string, dimensionJsonMap map[string]interface{}) error {
+	sensitiveKeys := slices.Clone(s.UnitManifest.SensitiveKeys)
+
+	if dimSensitiveKeys, ok := dimensionJsonMap[sensitiveKeysKey]; ok {
+		delete(dimensionJsonMap, sensitiveKeysKey)
+		dimSensitiveKeysList, ok := dimSensitiveKeys.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s must be a list of keys", sensitiveKeysKey)
+		}
+		for _, sensitiveKey := range dimSensitiveKeysList {
+			sensitiveKeys = append(sensitiveKeys, fmt.Sprint(sensitiveKey))
+		}
+	}
+
+	if len(sensitiveKeys) > 0 {
+		s.collectSensitiveValuesByKeys(dimensionJsonMap, sensitiveKeys, false, source)
+	}
+	return nil
+}
+
+func (s *State) collectSensitiveValuesByKeys(value interface{}, sensitiveKeys []string, sensitive bool, path string) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			s.collectSensitiveValuesByKeys(child, sensitiveKeys, sensitive || slices.Contains(sensitiveKeys, key), path+"/"+key)
+		}
+	case []interface{}:
+		for index, child := range value {
+			s.collectSensitiveValuesByKeys(child, sensitiveKeys, sensitive, path+"/"+strconv.Itoa(index))
+		}
+	case nil:
+	default:
+		if sensitive {
+			s.addSensitiveValue(path, fmt.Sprint(value))
+		}
+	}
+}
+
+// addSensitiveValue remembers value of the declared sensitive key (named by path in the warning) to be masked,
+// values too short to be masked are only reported
+func (s *State) addSensitiveValue(path string, value string) {
+	if value == "" {
+		return
+	}
+	if len(value) < minMaskedValueLength {
+		log.Printf("warning: sensitive value of %s is shorter than %d characters and is not masked in the output", path, minMaskedValueLength)
+		return
+	}
+	s.addMaskedValue(value)
+}
+
+// addMaskedValue remembers value to be masked whatever its length is, used for resolved secrets
+func (s *State) addMaskedValue(value string) {
+	if value == "" || slices.Contains(s.SensitiveValues, value) {
+		return
+	}
+	s.SensitiveValues = append(s.SensitiveValues, value)
+	// longer values first, so value containing another one is masked completely
+	sort.Slice(s.SensitiveValues, func(i, j int) bool { return len(s.SensitiveValues[i]) > len(s.SensitiveValues[j]) })
+}
+
+// MaskSecrets replaces all sensitive values in text with ***
+func MaskSecrets(text string, sensitiveValues []string) string {
+	for _, sensitiveValue := range sensitiveValues {
+		text = strings.ReplaceAll(text, sensitiveValue, maskedValue)
+	}
+	return text
+}
+
+// MaskingWriter masks sensitive values in everything written to the underlying writer.
+// Output is buffered only while its end could be the beginning of a sensitive value,
+// so prompts without new line are still shown immediately
+type MaskingWriter struct {
+	mu              sync.Mutex
+	out             io.Writer
+	sensitiveValues []string
+	buf             bytes.Buffer
+}
+
+func NewMaskingWriter(out io.Writer, sensitiveValues []string) *MaskingWriter {
+	return &MaskingWriter{out: out, sensitiveValues: sensitiveValues}
+}
+
+func (w *MaskingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf.Write(p)
+	text := w.buf.String()
+	w.buf.Reset()
+
+	// only the tail shorter than the longest sensitive value could be its beginning. The tail is held unmasked,
+	// as a complete short value could be the beginning of a longer one (pass of password)
+	holdFrom := len(text)
+	for i := max(0, len(text)-w.maxSensitiveLength()+1); i < len(text); i++ {
+		if w.isSensitivePrefix(text[i:]) {
+			holdFrom = i
+			break
+		}
+	}
+	// complete value overlapping the held tail is held too, otherwise it is written unmasked (abcd of xxabcd with cdxyz)
+	for moved := true; moved; {
+		moved = false
+		for _, sensitiveValue := range w.sensitiveValues {
+			for start := max(0, holdFrom-len(sensitiveValue)+1); start < holdFrom; start++ {
+				if strings.HasPrefix(text[start:], sensitiveValue) && start+len(sensitiveValue) > holdFrom {
+					holdFrom = start
+					moved = true
+					break
+				}
+			}
+		}
+	}
+	w.buf.WriteString(text[holdFrom:])
+
+	if _, err := io.WriteString(w.out, MaskSecrets(text[:holdFrom], w.sensitiveValues)); err != nil {
+		return len(p), err
+	}
+	return len(p), nil
+}
+
+// Flush writes the rest of the buffered output
+func (w *MaskingWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	_, err := io.WriteString(w.out, MaskSecrets(w.buf.String(), w.sensitiveValues))
+	w.buf.Reset()
+	return err
+}
+
+// isSensitivePrefix returns true if text is the beginning of a sensitive value, but not the whole value
+func (w *MaskingWriter) isSensitivePrefix(text string) bool {
+	for _, sensitiveValue := range w.sensitiveValues {
+		if len(text) < len(sensitiveValue) && strings.HasPrefix(sensitiveValue, text) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *MaskingWriter) maxSensitiveLength() int {
+	maxLength := 0
+	for _, sensitiveValue := range w.sensitiveValues {
+		maxLength = max(maxLength, len(sensitiveValue))
+	}
+	return maxLength
+}
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCollectSensitiveValues(t *testing.T) {
+	tests := []struct {
+		name          string
+		manifestKeys  []string
+		data          string
+		want          []string
+		wantWarnings  []string
+		wantDataAfter string
+	}{
+		{
+			name:          "$sensitive keys at any depth are collected and removed",
+			data:          `{"$sensitive": ["password"], "db": {"password": "secret-1", "user": "admin"}, "list": [{"password": "secret-22"}]}`,
+			want:          []string{"secret-22", "secret-1"},
+			wantDataAfter: `{"db": {"password": "secret-1", "user": "admin"}, "list": [{"password": "secret-22"}]}`,
+		},
+		{
+			name:         "sensitive_keys of the manifest mark the whole subtree",
+			manifestKeys: []string{"creds"},
+			data:         `{"creds": {"key": "abcd", "ids": ["wxyz", 12345]}, "other": "abcd-other"}`,
+			want:         []string{"12345", "abcd", "wxyz"},
+		},
+		{
+			name:         "short values are not masked with warning",
+			data:         `{"$sensitive": ["pin", "flag"], "pin": "123", "flag": true, "empty": ""}`,
+			want:         []string{"true"},
+			wantWarnings: []string{"sensitive value of account/dev/pin is shorter than 4 characters"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.data), &data); err != nil {
+				t.Fatal(err)
+			}
+			var logs bytes.Buffer
+			defer log.SetOutput(log.Writer())
+			log.SetOutput(&logs)
+
+			s := &State{UnitManifest: unitManifestStruct{SensitiveKeys: tt.manifestKeys}}
+			if err := s.collectSensitiveValues("account/dev", data); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(slices.Sorted(slices.Values(s.SensitiveValues)), slices.Sorted(slices.Values(tt.want))) {
+				t.Errorf("SensitiveValues = %v, want %v", s.SensitiveValues, tt.want)
+			}
+			for _, warning := range tt.wantWarnings {
+				if !strings.Contains(logs.String(), warning) {
+					t.Errorf("warning %q not logged, got:\n%s", warning, logs.String())
+				}
+			}
+			if strings.Contains(logs.String(), "123") {
+				t.Errorf("sensitive value is logged:\n%s", logs.String())
+			}
+			if tt.wantDataAfter != "" {
+				var want map[string]interface{}
+				json.Unmarshal([]byte(tt.wantDataAfter), &want)
+				if !reflect.DeepEqual(data, want) {
+					t.Errorf("data = %v, want %v", data, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMaskingWriter(t *testing.T) {
+	sensitiveValues := []string{"password123", "cdxyz", "abcd", "pass"}
+	tests := []struct {
+		name   string
+		writes []string
+		want   string
+	}{
+		{name: "no secrets", writes: []string{"hello ", "world\n"}, want: "hello world\n"},
+		{name: "secret in one write", writes: []string{"token=password123\n"}, want: "token=***\n"},
+		{name: "secret split between writes", writes: []string{"token=pass", "word1", "23 done\n"}, want: "token=*** done\n"},
+		{name: "shorter secret at the end", writes: []string{"p=pass"}, want: "p=***"},
+		{name: "prefix of secret which is not secret", writes: []string{"pa", "ss", "port\n"}, want: "***port\n"},
+		{name: "secret overlapping beginning of another secret", writes: []string{"xxabcd", "QQ\n"}, want: "xx***QQ\n"},
+		{name: "prompt without new line", writes: []string{"Enter a value: "}, want: "Enter a value: "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			w := NewMaskingWriter(&out, sensitiveValues)
+			for _, write := range tt.writes {
+				if _, err := w.Write([]byte(write)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.name == "prompt without new line" && out.String() != tt.want {
+				t.Errorf("prompt is buffered, written %q", out.String())
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("output = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
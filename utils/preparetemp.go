@@ -2,13 +2,21 @@ package utils
 
 import (
 	"fmt"
+	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/otiai10/copy"
 )
 
+// SetTempDirPurpose makes PrepareTemp use separate temp dir of the target, like render does not touch the temp dir of exec
+func (s *State) SetTempDirPurpose(purpose string) {
+	s.tempDirPurpose = purpose
+}
+
 func (s *State) PrepareTemp() error {
 	if s.StateS3Path == "" {
 		return fmt.Errorf("StateS3Path is empty")
@@ -16,17 +24,36 @@ func (s *State) PrepareTemp() error {
 
 	tmpFolderNameSuffix := s.OrgName + s.StateS3Path + s.UnitName
 	cmdTempDirFullPath := os.TempDir() + "/iacconsole-" + GetMD5Hash(tmpFolderNameSuffix)
+	if s.tempDirPurpose != "" {
+		cmdTempDirFullPath += "-" + s.tempDirPurpose
+	}
 
-	// Create temp directory if it doesn't exist
-	if err := os.MkdirAll(cmdTempDirFullPath, 0755); err != nil {
+	// Lock is held for the whole run, so concurrent runs against the same target do not race on the temp dir
+	if err := s.lockTemp(cmdTempDirFullPath); err != nil {
+		return err
+	}
+
+	// Create temp directory if it doesn't exist, only the user could read it as generated tfvars could contain secrets
+	if err := os.MkdirAll(cmdTempDirFullPath, 0700); err != nil {
 		return fmt.Errorf("failed to create temp directory: %v", err)
 	}
+	if err := os.Chmod(cmdTempDirFullPath, 0700); err != nil {
+		return fmt.Errorf("failed to restrict permissions of temp directory: %v", err)
+	}
+
+	// Remove files left from the previous runs which are not in the unit anymore
+	if err := s.removeStaleFromTemp(cmdTempDirFullPath); err != nil {
+		return fmt.Errorf("failed to remove stale files from temp dir: %v", err)
+	}
 
 	// Copy options to exclude certain files/directories
+	skip := func(src string) bool {
+		base := filepath.Base(src)
+		return base == ".terraform" || base == "unit_manifest.json"
+	}
 	opt := copy.Options{
 		Skip: func(info os.FileInfo, src string, dest string) (bool, error) {
-			base := filepath.Base(src)
-			return base == ".terraform" || base == "unit_manifest.json", nil
+			return skip(src), nil
 		},
 	}
 
@@ -35,6 +62,9 @@ func (s *State) PrepareTemp() error {
 		os.RemoveAll(cmdTempDirFullPath)
 		return fmt.Errorf("failed to copy unit to tempdir: %v", err)
 	}
+	if err := writeUnitFilesList(cmdTempDirFullPath, s.UnitPath, skip); err != nil {
+		return fmt.Errorf("failed to write list of unit files to temp dir: %v", err)
+	}
 
 	if s.SharedModulesPath != "" {
 		// Remove existing symlink if it exists
@@ -53,3 +83,108 @@ func (s *State) PrepareTemp() error {
 	log.Println("iacconsole prepared unit in temp dir: " + s.CmdWorkTempDir)
 	return nil
 }
+
+// unitFilesListName is the file in the temp dir listing files copied from the unit by the previous run
+const unitFilesListName = ".iacconsole_unit_files"
+
+// IsTempDirInternalFile returns true for the file of the temp dir used by iacconsole itself, not a part of the unit
+func IsTempDirInternalFile(relPath string) bool {
+	return relPath == unitFilesListName
+}
+
+// removeStaleFromTemp removes files and folders copied from the unit by the previous run which do not exist in the unit
+// anymore (or changed type) and generated iacconsole_ files. Files created by tofu or the user, like plan files, are kept,
+// as well as .terraform, lock and local state files
+func (s *State) removeStaleFromTemp(cmdTempDirFullPath string) error {
+	keep := map[string]bool{
+		".terraform":               true,
+		".terraform.lock.hcl":      true,
+		"terraform.tfstate":        true,
+		"terraform.tfstate.backup": true,
+		"terraform.tfstate.d":      true,
+	}
+
+	// generated vars files are removed on every run and generated again
+	tempEntries, err := os.ReadDir(cmdTempDirFullPath)
+	if err != nil {
+		return err
+	}
+	var stalePaths []string
+	for _, tempEntry := range tempEntries {
+		if strings.HasPrefix(tempEntry.Name(), "iacconsole_") {
+			stalePaths = append(stalePaths, tempEntry.Name())
+		}
+	}
+
+	unitFilesBytes, err := os.ReadFile(filepath.Join(cmdTempDirFullPath, unitFilesListName))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, relPath := range strings.Split(string(unitFilesBytes), "\n") {
+		if relPath != "" && !keep[relPath] && !slices.Contains(stalePaths, relPath) {
+			stalePaths = append(stalePaths, relPath)
+		}
+	}
+	// files are removed before the folders containing them
+	slices.Sort(stalePaths)
+	slices.Reverse(stalePaths)
+
+	for _, relPath := range stalePaths {
+		tempPath := filepath.Join(cmdTempDirFullPath, relPath)
+		tempFileInfo, err := os.Lstat(tempPath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		unitFileInfo, err := os.Lstat(filepath.Join(s.UnitPath, relPath))
+		if err == nil && unitFileInfo.Mode().Type() == tempFileInfo.Mode().Type() {
+			continue
+		}
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		if tempFileInfo.IsDir() && err != nil {
+			// folder removed from the unit is kept while it has files not copied from the unit
+			if removeErr := os.Remove(tempPath); removeErr == nil {
+				log.Println("iacconsole removed stale folder from temp dir: " + relPath)
+			}
+			continue
+		}
+		if err := os.RemoveAll(tempPath); err != nil {
+			return err
+		}
+		if !strings.HasPrefix(relPath, "iacconsole_") {
+			log.Println("iacconsole removed stale file from temp dir: " + relPath)
+		}
+	}
+	return nil
+}
+
+// writeUnitFilesList writes the list of files and folders copied from the unit, so the next run removes only them
+func writeUnitFilesList(cmdTempDirFullPath string, unitPath string, skip func(path string) bool) error {
+	var unitFiles []string
+	err := filepath.WalkDir(unitPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(unitPath, path)
+		if err != nil || relPath == "." {
+			return err
+		}
+		if skip(path) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		unitFiles = append(unitFiles, relPath)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(cmdTempDirFullPath, unitFilesListName), []byte(strings.Join(unitFiles, "\n")+"\n"), 0600)
+}
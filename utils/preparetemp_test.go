@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPrepareTempPurpose(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	unitPath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(unitPath, "main.tf"), []byte(""), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := &State{OrgName: "org", UnitName: "vpc", UnitPath: unitPath, StateS3Path: "org_org/vpc.tfstate"}
+	if err := run.PrepareTemp(); err != nil {
+		t.Fatal(err)
+	}
+	defer run.UnlockTemp()
+	// file left by the run, like .terraform or a plan, must survive reading outputs
+	planPath := filepath.Join(run.CmdWorkTempDir, "plan.out")
+	if err := os.WriteFile(planPath, []byte("plan"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		purpose string
+		sameDir bool
+	}{
+		{name: "the same target shares temp dir", sameDir: true},
+		{name: "reading outputs uses separate temp dir", purpose: "outputs"},
+		{name: "render uses separate temp dir", purpose: "render"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &State{OrgName: "org", UnitName: "vpc", UnitPath: unitPath, StateS3Path: "org_org/vpc.tfstate", tempDirPurpose: tt.purpose, FailOnLock: true}
+			if tt.sameDir {
+				// the run holds the lock of its temp dir
+				if err := s.PrepareTemp(); err == nil {
+					s.UnlockTemp()
+					t.Fatal("expected lock error for the temp dir of the run")
+				}
+				return
+			}
+			if err := s.PrepareTemp(); err != nil {
+				t.Fatal(err)
+			}
+			defer s.UnlockTemp()
+			if s.CmdWorkTempDir == run.CmdWorkTempDir {
+				t.Fatalf("temp dir %s is the same as of the run", s.CmdWorkTempDir)
+			}
+			if _, err := os.Stat(filepath.Join(s.CmdWorkTempDir, "main.tf")); err != nil {
+				t.Errorf("unit is not copied: %v", err)
+			}
+			if _, err := os.Stat(planPath); err != nil {
+				t.Errorf("file of the run was removed: %v", err)
+			}
+		})
+	}
+}
+
+func TestPrepareTempRemovesStaleUnitFiles(t *testing.T) {
+	tests := []struct {
+		name        string
+		unitFiles   map[string]string
+		createdFile []string
+		changeUnit  func(unitPath string) error
+		wantExist   []string
+		wantMissing []string
+	}{
+		{
+			name:        "file removed from the unit",
+			unitFiles:   map[string]string{"main.tf": "", "old.tf": ""},
+			changeUnit:  func(unitPath string) error { return os.Remove(filepath.Join(unitPath, "old.tf")) },
+			wantExist:   []string{"main.tf"},
+			wantMissing: []string{"old.tf"},
+		},
+		{
+			name:        "plan and files of tofu are kept",
+			unitFiles:   map[string]string{"main.tf": ""},
+			createdFile: []string{"tfplan", "plans/dev.tfplan", ".terraform/modules/modules.json", "terraform.tfstate"},
+			changeUnit:  func(unitPath string) error { return nil },
+			wantExist:   []string{"main.tf", "tfplan", "plans/dev.tfplan", ".terraform/modules/modules.json", "terraform.tfstate"},
+		},
+		{
+			name:        "generated vars files are removed",
+			unitFiles:   map[string]string{"main.tf": ""},
+			createdFile: []string{"iacconsole_account_vars.tf.json"},
+			changeUnit:  func(unitPath string) error { return nil },
+			wantExist:   []string{"main.tf"},
+			wantMissing: []string{"iacconsole_account_vars.tf.json"},
+		},
+		{
+			name:        "folder removed from the unit is kept with created files only",
+			unitFiles:   map[string]string{"main.tf": "", "modules/a/main.tf": "", "modules/b/main.tf": ""},
+			createdFile: []string{"modules/b/tfplan"},
+			changeUnit:  func(unitPath string) error { return os.RemoveAll(filepath.Join(unitPath, "modules")) },
+			wantExist:   []string{"main.tf", "modules/b/tfplan"},
+			wantMissing: []string{"modules/a", "modules/b/main.tf"},
+		},
+		{
+			name:      "file replaced by folder",
+			unitFiles: map[string]string{"main.tf": "", "vars": ""},
+			changeUnit: func(unitPath string) error {
+				if err := os.Remove(filepath.Join(unitPath, "vars")); err != nil {
+					return err
+				}
+				return writeTestFiles(unitPath, map[string]string{"vars/main.tf": ""})
+			},
+			wantExist: []string{"main.tf", "vars/main.tf"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TMPDIR", t.TempDir())
+			unitPath := t.TempDir()
+			if err := writeTestFiles(unitPath, tt.unitFiles); err != nil {
+				t.Fatal(err)
+			}
+
+			s := &State{OrgName: "org", UnitName: "vpc", UnitPath: unitPath, StateS3Path: "org_org/vpc.tfstate"}
+			if err := s.PrepareTemp(); err != nil {
+				t.Fatal(err)
+			}
+			s.UnlockTemp()
+			createdFiles := make(map[string]string)
+			for _, createdFile := range tt.createdFile {
+				createdFiles[createdFile] = "created"
+			}
+			if err := writeTestFiles(s.CmdWorkTempDir, createdFiles); err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.changeUnit(unitPath); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := s.PrepareTemp(); err != nil {
+				t.Fatal(err)
+			}
+			defer s.UnlockTemp()
+			for _, relPath := range tt.wantExist {
+				if _, err := os.Stat(filepath.Join(s.CmdWorkTempDir, relPath)); err != nil {
+					t.Errorf("%s is removed: %v", relPath, err)
+				}
+			}
+			for _, relPath := range tt.wantMissing {
+				if _, err := os.Stat(filepath.Join(s.CmdWorkTempDir, relPath)); err == nil {
+					t.Errorf("%s is not removed", relPath)
+				}
+			}
+		})
+	}
+}
+
+// writeTestFiles writes files with content by path relative to dir
+func writeTestFiles(dir string, files map[string]string) error {
+	for relPath, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, relPath)), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, relPath), []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// loadDimSchemas compiles schemas for the dimension: dim_schema from the inventory and schema file from schemas of the unit manifest.
+// Unknown keywords are ignored like JSON Schema requires, format is asserted, invalid schemas and unresolvable $ref are errors
+func (s *State) loadDimSchemas(dimKey string) ([]*jsonschema.Schema, error) {
+	var schemas []*jsonschema.Schema
+
+	dimSchema, err := s.GetDimData(dimKey, "dim_schema", true)
+	if err != nil {
+		return nil, err
+	}
+	if len(dimSchema) > 0 {
+		schema, err := compileSchema("urn:iacconsole:dim_schema:"+dimKey, dimSchema)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dim_schema of dimension %s: %v", dimKey, err)
+		}
+		schemas = append(schemas, schema)
+	}
+
+	if schemaPath, ok := s.UnitManifest.Schemas[dimKey]; ok {
+		if !filepath.IsAbs(schemaPath) {
+			schemaPath = filepath.Join(s.UnitPath, schemaPath)
+		}
+		schemaBytes, err := os.ReadFile(schemaPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema of dimension %s: %v", dimKey, err)
+		}
+		unitSchema, err := jsonschema.UnmarshalJSON(bytes.NewReader(schemaBytes))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse schema %s: %v", schemaPath, err)
+		}
+		// registered with the file path, so relative $ref to other schema files of the unit work
+		schema, err := compileSchema(schemaPath, unitSchema)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema %s: %v", schemaPath, err)
+		}
+		schemas = append(schemas, schema)
+	}
+	return schemas, nil
+}
+
+// compileSchema compiles JSON Schema (draft 2020-12 if $schema is not set) with format assertion
+func compileSchema(url string, schemaDoc interface{}) (*jsonschema.Schema, error) {
+	compiler := jsonschema.NewCompiler()
+	compiler.AssertFormat()
+	if err := compiler.AddResource(url, schemaDoc); err != nil {
+		return nil, err
+	}
+	return compiler.Compile(url)
+}
+
+// validateDimData validates dimension data against the schemas and returns all the errors like /path: got number, want string
+func validateDimData(schemas []*jsonschema.Schema, dimensionJsonMap map[string]interface{}) []string {
+	var validationErrors []string
+	for _, schema := range schemas {
+		err := schema.Validate(dimensionJsonMap)
+		if err == nil {
+			continue
+		}
+		validationError, ok := err.(*jsonschema.ValidationError)
+		if !ok {
+			validationErrors = append(validationErrors, "/: "+err.Error())
+			continue
+		}
+		schemaErrors := schemaOutputErrors(*validationError.DetailedOutput())
+		sort.Strings(schemaErrors)
+		validationErrors = append(validationErrors, schemaErrors...)
+	}
+	return validationErrors
+}
+
+// schemaOutputErrors returns the leaf errors of the output, the ones explaining why the value is not valid
+func schemaOutputErrors(output jsonschema.OutputUnit) []string {
+	if len(output.Errors) == 0 && output.Error != nil {
+		path := output.InstanceLocation
+		if path == "" {
+			path = "/"
+		}
+		return []string{path + ": " + output.Error.String()}
+	}
+	var validationErrors []string
+	for _, cause := range output.Errors {
+		validationErrors = append(validationErrors, schemaOutputErrors(cause)...)
+	}
+	return validationErrors
+}
+
+func jsonString(value interface{}) string {
+	valueBytes, _ := json.Marshal(value)
+	return string(valueBytes)
+}
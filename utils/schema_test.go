@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+func TestValidateDimData(t *testing.T) {
+	tests := []struct {
+		name       string
+		schema     string
+		data       string
+		wantErrors []string
+	}{
+		{
+			name:   "valid",
+			schema: `{"type": "object", "required": ["cidr"], "properties": {"cidr": {"type": "string", "format": "ipv4"}}}`,
+			data:   `{"cidr": "10.0.0.1"}`,
+		},
+		{
+			name:       "type and required",
+			schema:     `{"required": ["cidr", "zones"], "properties": {"cidr": {"type": "string"}}}`,
+			data:       `{"cidr": 10}`,
+			wantErrors: []string{"/: missing property 'zones'", "/cidr: got number, want string"},
+		},
+		{
+			name:       "format is asserted",
+			schema:     `{"properties": {"ip": {"format": "ipv4"}}}`,
+			data:       `{"ip": "999.0.0.1"}`,
+			wantErrors: []string{"/ip: '999.0.0.1' is not valid ipv4: decimal must be between 0 and 255"},
+		},
+		{
+			name:       "exclusiveMinimum and multipleOf",
+			schema:     `{"properties": {"size": {"exclusiveMinimum": 0, "multipleOf": 2}}}`,
+			data:       `{"size": 0}`,
+			wantErrors: []string{"/size: exclusiveMinimum: got 0, want 0"},
+		},
+		{
+			name:       "patternProperties and propertyNames",
+			schema:     `{"patternProperties": {"^n_": {"type": "number"}}, "propertyNames": {"maxLength": 4}}`,
+			data:       `{"n_a": "x", "long_key": 1}`,
+			wantErrors: []string{"/: maxLength: got 8, want 4", "/n_a: got string, want number"},
+		},
+		{
+			name:       "if then else",
+			schema:     `{"if": {"properties": {"public": {"const": true}}}, "then": {"required": ["igw"]}}`,
+			data:       `{"public": true}`,
+			wantErrors: []string{"/: missing property 'igw'"},
+		},
+		{
+			name:       "uniqueItems and not",
+			schema:     `{"properties": {"zones": {"uniqueItems": true}, "name": {"not": {"const": "default"}}}}`,
+			data:       `{"zones": ["a", "a"], "name": "default"}`,
+			wantErrors: []string{"/name: 'not' failed", "/zones: items at 0 and 1 are equal"},
+		},
+		{
+			name:   "local $ref with definitions",
+			schema: `{"properties": {"subnet": {"$ref": "#/definitions/subnet"}}, "definitions": {"subnet": {"type": "string"}}}`,
+			data:   `{"subnet": []}`,
+			wantErrors: []string{
+				"/subnet: got array, want string",
+			},
+		},
+		{
+			name:       "recursive $ref",
+			schema:     `{"$defs": {"node": {"type": "object", "properties": {"child": {"$ref": "#/$defs/node"}, "v": {"type": "number"}}}}, "$ref": "#/$defs/node"}`,
+			data:       `{"child": {"child": {"v": "x"}}}`,
+			wantErrors: []string{"/child/child/v: got string, want number"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var schemaDoc interface{}
+			if err := json.Unmarshal([]byte(tt.schema), &schemaDoc); err != nil {
+				t.Fatal(err)
+			}
+			schema, err := compileSchema("urn:iacconsole:test", schemaDoc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var data map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.data), &data); err != nil {
+				t.Fatal(err)
+			}
+
+			validationErrors := validateDimData([]*jsonschema.Schema{schema}, data)
+			if strings.Join(validationErrors, "\n") != strings.Join(tt.wantErrors, "\n") {
+				t.Errorf("errors:\n%s\nwant:\n%s", strings.Join(validationErrors, "\n"), strings.Join(tt.wantErrors, "\n"))
+			}
+		})
+	}
+}
+
+func TestCompileSchemaErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr string
+	}{
+		{name: "invalid keyword value", schema: `{"type": "strng"}`, wantErr: "jsonschema validation failed"},
+		{name: "invalid pattern", schema: `{"pattern": "("}`, wantErr: "pattern"},
+		{name: "missing $ref", schema: `{"$ref": "#/definitions/missing"}`, wantErr: "definitions/missing"},
+		{name: "$ref cycle", schema: `{"$defs": {"a": {"$ref": "#/$defs/b"}, "b": {"$ref": "#/$defs/a"}}, "$ref": "#/$defs/a"}`, wantErr: "cycle"},
+		{name: "remote $ref", schema: `{"$ref": "https://example.com/schema.json"}`, wantErr: "example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var schemaDoc interface{}
+			if err := json.Unmarshal([]byte(tt.schema), &schemaDoc); err != nil {
+				t.Fatal(err)
+			}
+			schema, err := compileSchema("urn:iacconsole:test", schemaDoc)
+			if err == nil && schema != nil {
+				// some of the cycles are found only during validation
+				err = schema.Validate(map[string]interface{}{})
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadDimSchemasRelativeRef(t *testing.T) {
+	unitPath := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(unitPath, "schemas"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"schemas/datacenter.json": `{"properties": {"cidr": {"$ref": "common.json#/$defs/cidr"}}}`,
+		"schemas/common.json":     `{"$defs": {"cidr": {"type": "string", "pattern": "/[0-9]+$"}}}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(unitPath, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := &State{UnitPath: unitPath, InventoryPath: t.TempDir(), UnitManifest: unitManifestStruct{Schemas: map[string]string{"datacenter": "schemas/datacenter.json"}}}
+	schemas, err := s.loadDimSchemas("datacenter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	validationErrors := validateDimData(schemas, map[string]interface{}{"cidr": "10.0.0.0"})
+	if len(validationErrors) != 1 || !strings.HasPrefix(validationErrors[0], "/cidr: ") {
+		t.Errorf("unexpected errors %v", validationErrors)
+	}
+}
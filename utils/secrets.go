@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// secretRefKey is the reserved key of the object in dimension data referencing secret, like {"$secret": "env:DB_PASS"}
+const secretRefKey = "$secret"
+
+// resolveSecretRefs replaces secret references at any depth of the data with their values and remembers them to be masked.
+// Returns true if any secret was resolved. Supported references:
+// env:NAME - env variable, file:/path - file content, sops:path#key - key (nested like db.password) of sops encrypted file
+func (s *State) resolveSecretRefs(dimensionJsonMap map[string]interface{}) (bool, error) {
+	resolved := false
+	for key, value := range dimensionJsonMap {
+		newValue, found, err := s.resolveSecretRefsIn(value)
+		if err != nil {
+			return false, fmt.Errorf("%s: %v", key, err)
+		}
+		if found {
+			dimensionJsonMap[key] = newValue
+			resolved = true
+		}
+	}
+	return resolved, nil
+}
+
+func (s *State) resolveSecretRefsIn(value interface{}) (interface{}, bool, error) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if secretRef, ok := value[secretRefKey]; ok && len(value) == 1 {
+			secretValue, err := s.readSecret(fmt.Sprint(secretRef))
+			if err != nil {
+				return nil, false, err
+			}
+			s.addMaskedValue(secretValue)
+			return secretValue, true, nil
+		}
+		found, err := s.resolveSecretRefs(value)
+		return value, found, err
+	case []interface{}:
+		resolved := false
+		for i, item := range value {
+			newItem, found, err := s.resolveSecretRefsIn(item)
+			if err != nil {
+				return nil, false, fmt.Errorf("%d: %v", i, err)
+			}
+			if found {
+				value[i] = newItem
+				resolved = true
+			}
+		}
+		return value, resolved, nil
+	}
+	return value, false, nil
+}
+
+// readSecret returns value of the secret reference, errors never contain the value
+func (s *State) readSecret(secretRef string) (string, error) {
+	source, ref, _ := strings.Cut(secretRef, ":")
+	if ref == "" {
+		return "", fmt.Errorf("invalid %s reference %q, expected env:NAME, file:path or sops:path#key", secretRefKey, secretRef)
+	}
+
+	switch source {
+	case "env":
+		secretValue, ok := os.LookupEnv(ref)
+		if !ok {
+			return "", fmt.Errorf("%s env variable %s is not set", secretRefKey, ref)
+		}
+		return secretValue, nil
+	case "file":
+		secretBytes, err := os.ReadFile(s.secretFilePath(ref))
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s file: %v", secretRefKey, err)
+		}
+		return strings.TrimRight(string(secretBytes), "\r\n"), nil
+	case "sops":
+		sopsPath, sopsKey, found := strings.Cut(ref, "#")
+		if !found || sopsKey == "" {
+			return "", fmt.Errorf("invalid %s reference %q, expected sops:path#key", secretRefKey, secretRef)
+		}
+		extract := ""
+		for _, keyPart := range strings.Split(sopsKey, ".") {
+			extract += fmt.Sprintf("[%q]", keyPart)
+		}
+		// sops finds the age key itself, in SOPS_AGE_KEY_FILE, SOPS_AGE_KEY or ~/.config/sops/age/keys.txt
+		secretBytes, err := runInDir(".", "sops", "--decrypt", "--extract", extract, s.secretFilePath(sopsPath))
+		if err != nil {
+			return "", fmt.Errorf("failed to decrypt %s with sops: %v", secretRef, err)
+		}
+		return strings.TrimRight(string(secretBytes), "\r\n"), nil
+	}
+	return "", fmt.Errorf("unknown %s source %q in %q, expected env, file or sops", secretRefKey, source, secretRef)
+}
+
+// secretFilePath returns path relative to inventory path of the org, if it is configured
+func (s *State) secretFilePath(path string) string {
+	if filepath.IsAbs(path) || s.InventoryPath == "" {
+		return path
+	}
+	return filepath.Join(s.InventoryPath, path)
+}
@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestResolveSecretRefs(t *testing.T) {
+	inventoryPath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(inventoryPath, "db.secret"), []byte("file-secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("IACCONSOLE_TEST_SECRET", "env-secret")
+
+	tests := []struct {
+		name        string
+		data        map[string]interface{}
+		want        map[string]interface{}
+		wantSecrets bool
+		wantErr     string
+	}{
+		{
+			name: "no secrets",
+			data: map[string]interface{}{"a": "b", "n": 1.0},
+			want: map[string]interface{}{"a": "b", "n": 1.0},
+		},
+		{
+			name:        "env secret",
+			data:        map[string]interface{}{"password": map[string]interface{}{"$secret": "env:IACCONSOLE_TEST_SECRET"}},
+			want:        map[string]interface{}{"password": "env-secret"},
+			wantSecrets: true,
+		},
+		{
+			name:        "file secret relative to inventory, nested in list",
+			data:        map[string]interface{}{"dbs": []interface{}{map[string]interface{}{"password": map[string]interface{}{"$secret": "file:db.secret"}}}},
+			want:        map[string]interface{}{"dbs": []interface{}{map[string]interface{}{"password": "file-secret"}}},
+			wantSecrets: true,
+		},
+		{
+			name: "object with $secret and other keys is not a reference",
+			data: map[string]interface{}{"x": map[string]interface{}{"$secret": "env:NOPE", "other": 1.0}},
+			want: map[string]interface{}{"x": map[string]interface{}{"$secret": "env:NOPE", "other": 1.0}},
+		},
+		{
+			name:    "missing env",
+			data:    map[string]interface{}{"password": map[string]interface{}{"$secret": "env:IACCONSOLE_TEST_MISSING"}},
+			wantErr: "password: $secret env variable IACCONSOLE_TEST_MISSING is not set",
+		},
+		{
+			name:    "unknown source",
+			data:    map[string]interface{}{"password": map[string]interface{}{"$secret": "vault:x"}},
+			wantErr: `unknown $secret source "vault"`,
+		},
+		{
+			name:    "sops without key",
+			data:    map[string]interface{}{"password": map[string]interface{}{"$secret": "sops:file.yaml"}},
+			wantErr: "expected sops:path#key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &State{InventoryPath: inventoryPath}
+			hasSecrets, err := s.resolveSecretRefs(tt.data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hasSecrets != tt.wantSecrets {
+				t.Errorf("hasSecrets = %v, want %v", hasSecrets, tt.wantSecrets)
+			}
+			if !reflect.DeepEqual(tt.data, tt.want) {
+				t.Errorf("data = %v, want %v", tt.data, tt.want)
+			}
+			for _, secret := range []string{"env-secret", "file-secret"} {
+				if strings.Contains(fmt.Sprint(tt.want), secret) && !slices.Contains(s.SensitiveValues, secret) {
+					t.Errorf("resolved secret %q is not masked", secret)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteTfvarsMapsPermissions(t *testing.T) {
+	dir := t.TempDir()
+	if err := writeTfvarsMaps(map[string]interface{}{"iacconsole_a_data": map[string]interface{}{"password": "x"}}, "a", dir, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"iacconsole_a.auto.tfvars.json", "iacconsole_a_vars.tf.json"} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("%s has permissions %v, want 0600", name, info.Mode().Perm())
+		}
+	}
+}
@@ -1,5 +1,13 @@
 package utils
 
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
 type State struct {
 	UnitName          string
 	OrgName           string
@@ -9,15 +17,62 @@ type State struct {
 	InventoryPath     string
 	UnitManifestPath  string
 	ParsedDimensions  map[string]string
+	MultiDimensions   map[string][]string
 	CmdWorkTempDir    string
 	UnitManifest      unitManifestStruct
 	StateS3Path       string
-	IacconsoleApiUrl  string
+	ApiEndpoint       *ApiEndpoint
 	Workspace         string
+	FailOnLock        bool
+	SensitiveValues   []string
+	Verbose           bool
+	InventorySource   string
+	Offline           bool
+	tempLockFile      *os.File
+	inventoryProvider InventoryProvider
+	// tempDirPurpose separates temp dir of the unit used only for reading (like outputs for inputs_from) from the one of its runs
+	tempDirPurpose string
 }
 
 type unitManifestStruct struct {
-	Dimensions []string
+	Dimensions    []unitDimension     `json:"dimensions"`
+	DependsOn     []string            `json:"depends_on"`
+	InputsFrom    []unitInput         `json:"inputs_from"`
+	Hooks         map[string][]string `json:"hooks"`
+	SensitiveKeys []string            `json:"sensitive_keys"`
+	MergeDefaults *bool               `json:"merge_defaults"`
+	Schemas       map[string]string   `json:"schemas"`
+	// MultiDimensions are dimensions accepting several values, like -d dc:x -d dc:y
+	MultiDimensions []string `json:"multi_dimensions"`
+	// unknownFields of the manifest, reported as warnings or errors in strict mode
+	unknownFields []string
+}
+
+// unitDimension is a dimension of the unit manifest, declared as "name" or as an object
+type unitDimension struct {
+	Name string `json:"name"`
+	// Required is true if not set, not passed optional dimension is null in tf-code
+	Required *bool `json:"required"`
+	// Default values are used if the dimension is not passed with -d arg
+	Default stringList `json:"default"`
+	// Allowed is the list of allowed values or a regex, which should match the whole value
+	Allowed     json.RawMessage `json:"allowed"`
+	Description string          `json:"description"`
+	// StatePath is true if not set, false excludes the dimension from the state path
+	StatePath *bool `json:"state_path"`
+	Multi     bool  `json:"multi"`
+	// Type is HCL type constraint of the dimension data, like object({cidr = string}), inferred from the data if not set
+	Type string `json:"type"`
+	// allowedValues or allowedRegex are parsed from Allowed
+	allowedValues []string
+	allowedRegex  *regexp.Regexp
+	// dataType is parsed from Type
+	dataType cty.Type
+}
+
+type unitInput struct {
+	Unit    string   `json:"unit"`
+	Outputs []string `json:"outputs"`
 }
 
 type IaCConsoleDBResponse struct {
@@ -27,6 +82,8 @@ type IaCConsoleDBResponse struct {
 
 type DimensionInIaCConsoleDB struct {
 	ID        string
+	DimKey    string
+	DimValue  string
 	WorkSpace string
 	DimData   map[string]interface{}
 }
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"syscall"
+)
+
+// lockTemp takes exclusive lock on the temp dir with the lock file next to it, so the lock survives temp dir removal.
+// If FailOnLock is set and the temp dir is locked by another run, error is returned instead of waiting
+func (s *State) lockTemp(cmdTempDirFullPath string) error {
+	lockFilePath := cmdTempDirFullPath + ".lock"
+	lockFile, err := os.OpenFile(lockFilePath, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open lock file: %v", err)
+	}
+
+	err = syscall.Flock(int(lockFile.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		if s.FailOnLock {
+			lockFile.Close()
+			return fmt.Errorf("temp dir %s is locked by another iacconsole-cli run for the same org, unit and dimensions", cmdTempDirFullPath)
+		}
+		log.Println("iacconsole waiting for another run to release lock on temp dir: " + cmdTempDirFullPath)
+		err = syscall.Flock(int(lockFile.Fd()), syscall.LOCK_EX)
+	}
+	if err != nil {
+		lockFile.Close()
+		return fmt.Errorf("failed to lock temp dir: %v", err)
+	}
+
+	s.tempLockFile = lockFile
+	return nil
+}
+
+// UnlockTemp releases lock on the temp dir taken by PrepareTemp, lock is released on process exit anyway
+func (s *State) UnlockTemp() {
+	if s.tempLockFile == nil {
+		return
+	}
+	if err := syscall.Flock(int(s.tempLockFile.Fd()), syscall.LOCK_UN); err != nil {
+		log.Printf("failed to unlock temp dir: %v", err)
+	}
+	s.tempLockFile.Close()
+	s.tempLockFile = nil
+}
@@ -1,26 +1,239 @@
 package utils
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"log"
 	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
 )
 
-func (s *State) ParseUnitManifest(unitManifestFileName string) {
+func (s *State) ParseUnitManifest(unitManifestFileName string) error {
 	unitManifestPath := s.UnitPath + "/" + unitManifestFileName
-	// Let's first read the `config.json` file
-	content, err := os.ReadFile(unitManifestPath)
+	unitManifest, err := ReadUnitManifest(unitManifestPath)
 	if err != nil {
-		log.Fatal("iacconsole error when opening file: ", err)
+		return err
+	}
+
+	if len(unitManifest.unknownFields) > 0 {
+		if s.GetBoolFromViperByOrgOrDefault("strict_manifest") {
+			return fmt.Errorf("unit manifest %s has unknown fields (strict_manifest is enabled): %s", unitManifestPath, strings.Join(unitManifest.unknownFields, ", "))
+		}
+		log.Printf("WARNING: unit manifest %s has unknown fields: %s", unitManifestPath, strings.Join(unitManifest.unknownFields, ", "))
 	}
 
-	// Now let's unmarshall the data into `payload`
+	s.UnitManifest = unitManifest
+	log.Println("iacconsole loaded unit manifest: " + unitManifestPath)
+	return nil
+}
+
+// ReadUnitManifest reads and unmarshals unit_manifest.json from the given path, all the problems of dimensions are reported at once
+func ReadUnitManifest(unitManifestPath string) (unitManifestStruct, error) {
 	var unitManifest unitManifestStruct
+
+	content, err := os.ReadFile(unitManifestPath)
+	if err != nil {
+		return unitManifest, fmt.Errorf("failed to open unit manifest: %v", err)
+	}
+
 	err = json.Unmarshal(content, &unitManifest)
 	if err != nil {
-		log.Fatal("iacconsole error during Unmarshal(): ", err)
+		return unitManifest, fmt.Errorf("failed to unmarshal unit manifest %s: %v", unitManifestPath, err)
 	}
 
-	s.UnitManifest = unitManifest
-	log.Println("iacconsole loaded unit manifest: " + unitManifestPath)
+	var manifestErrors []string
+	var names []string
+	for i := range unitManifest.Dimensions {
+		dimension := &unitManifest.Dimensions[i]
+		if slices.Contains(names, dimension.Name) {
+			manifestErrors = append(manifestErrors, "dimension "+dimension.Name+" is declared several times")
+		}
+		names = append(names, dimension.Name)
+		if dimension.Multi && !slices.Contains(unitManifest.MultiDimensions, dimension.Name) {
+			unitManifest.MultiDimensions = append(unitManifest.MultiDimensions, dimension.Name)
+		}
+		manifestErrors = append(manifestErrors, dimension.validate()...)
+	}
+	for _, dimension := range unitManifest.MultiDimensions {
+		if !slices.Contains(names, dimension) {
+			manifestErrors = append(manifestErrors, "multi dimension "+dimension+" is not in dimensions")
+		}
+	}
+	for _, dimension := range unitManifest.Dimensions {
+		if len(dimension.Default) > 1 && !slices.Contains(unitManifest.MultiDimensions, dimension.Name) {
+			manifestErrors = append(manifestErrors, "dimension "+dimension.Name+" is not multi, but has several default values")
+		}
+	}
+	if len(manifestErrors) > 0 {
+		return unitManifest, fmt.Errorf("invalid unit manifest %s:\n%s", unitManifestPath, strings.Join(manifestErrors, "\n"))
+	}
+	return unitManifest, nil
+}
+
+// UnmarshalJSON reads the manifest and collects its unknown fields and unknown fields of dimension objects
+func (m *unitManifestStruct) UnmarshalJSON(content []byte) error {
+	// type without methods, to not call UnmarshalJSON recursively
+	type unitManifestFields unitManifestStruct
+	if err := json.Unmarshal(content, (*unitManifestFields)(m)); err != nil {
+		return err
+	}
+
+	var manifestFields struct {
+		Dimensions []json.RawMessage `json:"dimensions"`
+	}
+	if err := json.Unmarshal(content, &manifestFields); err != nil {
+		return err
+	}
+	m.unknownFields = unknownJsonFields(content, unitManifestStruct{}, "")
+	for i, dimensionContent := range manifestFields.Dimensions {
+		if bytes.HasPrefix(bytes.TrimSpace(dimensionContent), []byte("{")) {
+			m.unknownFields = append(m.unknownFields, unknownJsonFields(dimensionContent, unitDimension{}, "dimensions."+m.Dimensions[i].Name+".")...)
+		}
+	}
+	return nil
+}
+
+// unknownJsonFields returns keys of the JSON object which are not json tags of the struct, with the prefix.
+// Keys are compared case-insensitively like encoding/json matches them
+func unknownJsonFields(content []byte, structValue interface{}, prefix string) []string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(content, &fields); err != nil {
+		return nil
+	}
+
+	structType := reflect.TypeOf(structValue)
+	var knownFields []string
+	for i := 0; i < structType.NumField(); i++ {
+		if tag, _, _ := strings.Cut(structType.Field(i).Tag.Get("json"), ","); tag != "" && tag != "-" {
+			knownFields = append(knownFields, tag)
+		}
+	}
+
+	var unknownFields []string
+	for field := range fields {
+		if !slices.ContainsFunc(knownFields, func(knownField string) bool { return strings.EqualFold(knownField, field) }) {
+			unknownFields = append(unknownFields, prefix+field)
+		}
+	}
+	sort.Strings(unknownFields)
+	return unknownFields
+}
+
+// UnmarshalJSON reads the dimension declared as "name" or as {"name": "name", ...}
+func (d *unitDimension) UnmarshalJSON(content []byte) error {
+	if err := json.Unmarshal(content, &d.Name); err == nil {
+		return nil
+	}
+	type unitDimensionFields unitDimension
+	if err := json.Unmarshal(content, (*unitDimensionFields)(d)); err != nil {
+		return fmt.Errorf("dimension should be a name or an object: %v", err)
+	}
+	return nil
+}
+
+// validate parses allowed values and checks the dimension declaration
+func (d *unitDimension) validate() []string {
+	if d.Name == "" {
+		return []string{"dimension without name"}
+	}
+
+	var dimensionErrors []string
+	if len(d.Allowed) > 0 {
+		var allowedRegex string
+		if err := json.Unmarshal(d.Allowed, &d.allowedValues); err == nil {
+			if len(d.allowedValues) == 0 {
+				dimensionErrors = append(dimensionErrors, "dimension "+d.Name+": allowed is an empty list")
+			}
+		} else if err := json.Unmarshal(d.Allowed, &allowedRegex); err == nil {
+			if d.allowedRegex, err = regexp.Compile("^(?:" + allowedRegex + ")$"); err != nil {
+				dimensionErrors = append(dimensionErrors, "dimension "+d.Name+": invalid allowed regex: "+err.Error())
+			}
+		} else {
+			dimensionErrors = append(dimensionErrors, "dimension "+d.Name+": allowed should be a list of values or a regex")
+		}
+	}
+	for _, defaultValue := range d.Default {
+		if err := d.checkAllowed(defaultValue); err != nil {
+			dimensionErrors = append(dimensionErrors, "default of "+err.Error())
+		}
+	}
+	if d.Type != "" {
+		var err error
+		if d.dataType, err = parseHclType(d.Type); err != nil {
+			dimensionErrors = append(dimensionErrors, "dimension "+d.Name+": "+err.Error())
+		}
+	}
+	if d.Required != nil && *d.Required && len(d.Default) > 0 {
+		dimensionErrors = append(dimensionErrors, "dimension "+d.Name+": required dimension with default value, set only one of them")
+	}
+	return dimensionErrors
+}
+
+// IsRequired returns true if the dimension must be passed with -d arg
+func (d unitDimension) IsRequired() bool {
+	return (d.Required == nil || *d.Required) && len(d.Default) == 0
+}
+
+// InStatePath returns true if the dimension is part of the state path
+func (d unitDimension) InStatePath() bool {
+	return d.StatePath == nil || *d.StatePath
+}
+
+// checkAllowed returns error if the value is not in allowed values or does not match allowed regex
+func (d unitDimension) checkAllowed(dimValue string) error {
+	if d.allowedValues != nil && !slices.Contains(d.allowedValues, dimValue) {
+		return fmt.Errorf("dimension %s: value %s is not allowed, expected one of: %s", d.Name, dimValue, strings.Join(d.allowedValues, ", "))
+	}
+	if d.allowedRegex != nil && !d.allowedRegex.MatchString(dimValue) {
+		return fmt.Errorf("dimension %s: value %s does not match allowed %s", d.Name, dimValue, strings.TrimSuffix(strings.TrimPrefix(d.allowedRegex.String(), "^(?:"), ")$"))
+	}
+	return nil
+}
+
+// Dimension returns declaration of the dimension, false if it is not in the manifest
+func (m unitManifestStruct) Dimension(name string) (unitDimension, bool) {
+	for _, dimension := range m.Dimensions {
+		if dimension.Name == name {
+			return dimension, true
+		}
+	}
+	return unitDimension{}, false
+}
+
+// IsMultiDimension returns true if the dimension accepts several values
+func (m unitManifestStruct) IsMultiDimension(dimension string) bool {
+	return slices.Contains(m.MultiDimensions, dimension)
+}
+
+// Dependencies returns units from depends_on and inputs_from which must be deployed before the unit
+func (m unitManifestStruct) Dependencies() []string {
+	dependencies := slices.Clone(m.DependsOn)
+	for _, input := range m.InputsFrom {
+		if !slices.Contains(dependencies, input.Unit) {
+			dependencies = append(dependencies, input.Unit)
+		}
+	}
+	return dependencies
+}
+
+// stringList is a list of strings, which could be set in JSON as a single string too
+type stringList []string
+
+func (l *stringList) UnmarshalJSON(content []byte) error {
+	var value string
+	if err := json.Unmarshal(content, &value); err == nil {
+		*l = stringList{value}
+		return nil
+	}
+	var values []string
+	if err := json.Unmarshal(content, &values); err != nil {
+		return fmt.Errorf("should be a string or a list of strings")
+	}
+	*l = values
+	return nil
 }
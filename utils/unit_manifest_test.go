@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadUnitManifest(t *testing.T) {
+	tests := []struct {
+		name              string
+		manifest          string
+		wantUnknownFields []string
+		wantMulti         []string
+		wantErr           string
+	}{
+		{
+			name:     "names and objects",
+			manifest: `{"dimensions": ["account", {"name": "region", "multi": true, "default": ["eu", "us"], "allowed": ["eu", "us"]}], "depends_on": ["vpc"]}`,
+			wantMulti: []string{
+				"region",
+			},
+		},
+		{
+			name:     "keys are matched case-insensitively like encoding/json does",
+			manifest: `{"Dimensions": [{"Name": "account", "Description": "AWS account"}], "DEPENDS_ON": ["vpc"]}`,
+		},
+		{
+			name:              "unknown fields",
+			manifest:          `{"dimensions": [{"name": "account", "requred": false}], "depend_on": ["vpc"]}`,
+			wantUnknownFields: []string{"depend_on", "dimensions.account.requred"},
+		},
+		{
+			name:     "allowed regex",
+			manifest: `{"dimensions": [{"name": "account", "allowed": "dev|prod", "default": "dev"}]}`,
+		},
+		{
+			name:     "default not allowed",
+			manifest: `{"dimensions": [{"name": "account", "allowed": "dev|prod", "default": "test"}]}`,
+			wantErr:  "default of dimension account: value test does not match allowed dev|prod",
+		},
+		{
+			name:     "duplicate dimension",
+			manifest: `{"dimensions": ["account", {"name": "account"}]}`,
+			wantErr:  "dimension account is declared several times",
+		},
+		{
+			name:     "several defaults of not multi dimension",
+			manifest: `{"dimensions": [{"name": "account", "default": ["a", "b"]}]}`,
+			wantErr:  "dimension account is not multi, but has several default values",
+		},
+		{
+			name:     "multi dimension not in dimensions",
+			manifest: `{"dimensions": ["account"], "multi_dimensions": ["region"]}`,
+			wantErr:  "multi dimension region is not in dimensions",
+		},
+		{
+			name:     "required with default",
+			manifest: `{"dimensions": [{"name": "account", "required": true, "default": "dev"}]}`,
+			wantErr:  "required dimension with default value",
+		},
+		{
+			name:     "invalid type",
+			manifest: `{"dimensions": [{"name": "account", "type": "object({cidr = strng})"}]}`,
+			wantErr:  "dimension account: invalid type",
+		},
+		{
+			name:     "invalid allowed",
+			manifest: `{"dimensions": [{"name": "account", "allowed": 1}]}`,
+			wantErr:  "allowed should be a list of values or a regex",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifestPath := filepath.Join(t.TempDir(), "unit_manifest.json")
+			if err := os.WriteFile(manifestPath, []byte(tt.manifest), 0644); err != nil {
+				t.Fatal(err)
+			}
+			manifest, err := ReadUnitManifest(manifestPath)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(manifest.unknownFields, tt.wantUnknownFields) {
+				t.Errorf("unknownFields = %v, want %v", manifest.unknownFields, tt.wantUnknownFields)
+			}
+			if !reflect.DeepEqual(manifest.MultiDimensions, tt.wantMulti) {
+				t.Errorf("MultiDimensions = %v, want %v", manifest.MultiDimensions, tt.wantMulti)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DiscoverUnits walks unitsOrgPath and returns manifests of all units (folders with unit_manifest.json) keyed by unit name
+func DiscoverUnits(unitsOrgPath string, unitManifestFileName string) (map[string]unitManifestStruct, error) {
+	units := make(map[string]unitManifestStruct)
+
+	err := filepath.WalkDir(unitsOrgPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if d.Name() == ".terraform" {
+			return filepath.SkipDir
+		}
+
+		unitManifestPath := filepath.Join(path, unitManifestFileName)
+		if _, err := os.Stat(unitManifestPath); err != nil {
+			return nil
+		}
+
+		unitName, err := filepath.Rel(unitsOrgPath, path)
+		if err != nil {
+			return err
+		}
+		unitManifest, err := ReadUnitManifest(unitManifestPath)
+		if err != nil {
+			return err
+		}
+		units[filepath.ToSlash(unitName)] = unitManifest
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover units in %s: %v", unitsOrgPath, err)
+	}
+
+	return units, nil
+}
+
+// SortUnitsByDependencies returns unit names in topological order of depends_on and inputs_from, dependencies first
+func SortUnitsByDependencies(units map[string]unitManifestStruct) ([]string, error) {
+	inDegree := make(map[string]int, len(units))
+	dependents := make(map[string][]string, len(units))
+
+	for unitName, unitManifest := range units {
+		dependencies := unitManifest.Dependencies()
+		inDegree[unitName] = len(dependencies)
+		for _, dependency := range dependencies {
+			if _, ok := units[dependency]; !ok {
+				return nil, fmt.Errorf("unit %s depends on unknown unit %s", unitName, dependency)
+			}
+			dependents[dependency] = append(dependents[dependency], unitName)
+		}
+	}
+
+	// Kahn's algorithm, ready units are sorted by name to keep the order stable between runs
+	var ready []string
+	for unitName, degree := range inDegree {
+		if degree == 0 {
+			ready = append(ready, unitName)
+		}
+	}
+	sort.Strings(ready)
+
+	sortedUnits := make([]string, 0, len(units))
+	for len(ready) > 0 {
+		unitName := ready[0]
+		ready = ready[1:]
+		sortedUnits = append(sortedUnits, unitName)
+
+		var unlocked []string
+		for _, dependent := range dependents[unitName] {
+			inDegree[dependent]--
+			if inDegree[dependent] == 0 {
+				unlocked = append(unlocked, dependent)
+			}
+		}
+		ready = append(ready, unlocked...)
+		sort.Strings(ready)
+	}
+
+	if len(sortedUnits) != len(units) {
+		var cycled []string
+		for unitName, degree := range inDegree {
+			if degree > 0 {
+				cycled = append(cycled, unitName)
+			}
+		}
+		sort.Strings(cycled)
+		return nil, fmt.Errorf("dependency cycle detected between units: %s", strings.Join(cycled, ", "))
+	}
+
+	return sortedUnits, nil
+}
@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSortUnitsByDependencies(t *testing.T) {
+	tests := []struct {
+		name    string
+		units   map[string]unitManifestStruct
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "dependencies first, independent units by name",
+			units: map[string]unitManifestStruct{
+				"app":     {DependsOn: []string{"vpc", "db"}},
+				"db":      {DependsOn: []string{"vpc"}},
+				"vpc":     {},
+				"dns":     {},
+				"monitor": {DependsOn: []string{"app"}},
+			},
+			want: []string{"dns", "vpc", "db", "app", "monitor"},
+		},
+		{
+			name: "inputs_from is a dependency",
+			units: map[string]unitManifestStruct{
+				"app": {InputsFrom: []unitInput{{Unit: "vpc"}}},
+				"vpc": {},
+			},
+			want: []string{"vpc", "app"},
+		},
+		{
+			name: "the same unit in depends_on and inputs_from",
+			units: map[string]unitManifestStruct{
+				"app": {DependsOn: []string{"vpc"}, InputsFrom: []unitInput{{Unit: "vpc"}}},
+				"vpc": {},
+			},
+			want: []string{"vpc", "app"},
+		},
+		{
+			name: "unknown dependency",
+			units: map[string]unitManifestStruct{
+				"app": {DependsOn: []string{"vpc"}},
+			},
+			wantErr: "unit app depends on unknown unit vpc",
+		},
+		{
+			name: "cycle",
+			units: map[string]unitManifestStruct{
+				"a":   {DependsOn: []string{"b"}},
+				"b":   {DependsOn: []string{"c"}},
+				"c":   {DependsOn: []string{"a"}},
+				"vpc": {},
+			},
+			wantErr: "dependency cycle detected between units: a, b, c",
+		},
+		{
+			name: "unit depends on itself",
+			units: map[string]unitManifestStruct{
+				"a": {DependsOn: []string{"a"}},
+			},
+			wantErr: "dependency cycle detected between units: a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SortUnitsByDependencies(tt.units)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscoverUnits(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "nested units and .terraform is skipped",
+			files: map[string]string{
+				"vpc/unit_manifest.json":                         `{"dimensions": ["account"]}`,
+				"apps/web/unit_manifest.json":                    `{"dimensions": ["account"], "depends_on": ["vpc"]}`,
+				"apps/README.md":                                 `not a unit`,
+				"vpc/.terraform/modules/x/unit_manifest.json":    `{}`,
+				"apps/web/.terraform/modules/unit_manifest.json": `{}`,
+			},
+			want: []string{"apps/web", "vpc"},
+		},
+		{
+			name: "invalid manifest",
+			files: map[string]string{
+				"vpc/unit_manifest.json": `{"dimensions": [`,
+			},
+			wantErr: "failed to discover units in",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unitsOrgPath := t.TempDir()
+			for filePath, content := range tt.files {
+				fullPath := filepath.Join(unitsOrgPath, filePath)
+				if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			units, err := DiscoverUnits(unitsOrgPath, "unit_manifest.json")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := slices.Sorted(maps.Keys(units))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("units = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}